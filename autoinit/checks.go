@@ -1,28 +1,34 @@
 package autoinit
 
 import (
-	"os"
+	"context"
 	"os/exec"
-)
 
-const (
-	taskInstallPkg = "github.com/go-task/task/v3/cmd/task@latest"
+	"code-template/services"
 )
 
 func isGoInstalled() bool {
-	_, err := exec.LookPath("go")
-	return err == nil
+	return services.GoRequirement.Found()
 }
 
 func isTaskGloballyAvailable() bool {
-	_, err := exec.LookPath("task")
-	return err == nil
+	return services.TaskRequirement.Found()
 }
 
-func installTaskGlobally() error {
-	printInfo("Installing go-task globally...")
-	cmd := exec.Command("go", "install", taskInstallPkg)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// installTaskGlobally installs go-task under ctx, writing the output of go
+// install to a log rather than the terminal.
+func (o Options) installTaskGlobally(ctx context.Context) error {
+	o.printInfo("Installing go-task globally...")
+	log, err := services.OpenLog("task", nil)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	pkg := services.TaskTool.Package(services.TaskInstallPath)
+	if err := services.Run(services.WithLog(ctx, log), exec.Command("go", "install", pkg.InstallPath)); err != nil {
+		o.printInfo("Output written to " + log.Path)
+		return err
+	}
+	return nil
 }
@@ -1,73 +1,56 @@
 package autoinit
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"fmt"
 
+	"code-template/helpers"
 	yamlhelper "code-template/helpers/yaml"
 )
 
-const (
-	binDir               = ".bin"
-	golangciBinaryName   = "golangci-lint"
-	golangciConfigFile   = ".golangci.yml"
-	taskfileFile         = "Taskfile.yml"
-	codeTemplateFileName = "code-template.yml"
-)
-
-func detectInstalledModules() map[string]int {
-	detected := make(map[string]int)
+const codeTemplateFileName = "code-template.yml"
 
-	if isGolangciInstalled() {
-		detected["golangci"] = 1
-	}
+func createConfig() error {
+	return yamlhelper.WriteYAML(codeTemplateFileName, map[string]any{})
+}
 
-	// Detect task modules
-	if hasTask, _ := hasTaskfileTask("go-lint"); hasTask {
-		detected["task-go-lint"] = 1
+// planAdopt describes what adopt would offer, for a dry run.
+func planAdopt(detected []helpers.Detection) []string {
+	lines := make([]string, 0, len(detected))
+	for _, d := range detected {
+		lines = append(lines, "Would offer to adopt "+d.String())
 	}
-	if hasTask, _ := hasTaskfileTask("go-test"); hasTask {
-		detected["task-go-test"] = 1
-	}
-	if hasTask, _ := hasTaskfileTask("tdd-test"); hasTask {
-		detected["task-tdd-test"] = 1
-	}
-
-	return detected
+	return lines
 }
 
-func isGolangciInstalled() bool {
-	if _, err := os.Stat(golangciConfigFile); os.IsNotExist(err) {
-		return false
+// adopt lists the modules found set up without code-template and, once
+// confirmed, records them in code-template.yml at their detected versions.
+func (o Options) adopt(ctx context.Context, detected []helpers.Detection) error {
+	if len(detected) == 0 {
+		return nil
 	}
 
-	binPath := filepath.Join(binDir, golangciBinaryName)
-	if _, err := os.Stat(binPath); os.IsNotExist(err) {
-		return false
+	o.printInfo(fmt.Sprintf("Detected %d installed module(s):", len(detected)))
+	for _, d := range detected {
+		o.printInfo("  " + d.String())
 	}
-
-	return true
-}
-
-func hasTaskfileTask(taskName string) (bool, error) {
-	data, err := yamlhelper.ReadYAML(taskfileFile)
+	answer, err := o.confirm(PromptAdopt, "Adopt them into "+codeTemplateFileName+"?", true)
 	if err != nil {
-		return false, err
+		return err
 	}
-
-	tasks, ok := data["tasks"].(map[string]any)
-	if !ok {
-		return false, nil
+	if !answer {
+		o.printWarning("Left detected modules unrecorded; run 'code-template init' to adopt them later")
+		return nil
 	}
 
-	_, exists := tasks[taskName]
-	return exists, nil
-}
-
-func createConfig(modules map[string]int) error {
-	data := make(map[string]any)
-	for key, version := range modules {
-		data[key] = version
+	if err := helpers.Adopt(ctx, detected); err != nil {
+		o.printError("Failed to adopt detected modules")
+		return &InitError{
+			Step:    "adopt",
+			Message: "Failed to adopt detected modules",
+			Err:     err,
+		}
 	}
-	return yamlhelper.WriteYAML(codeTemplateFileName, data)
+	o.printSuccess(fmt.Sprintf("Adopted %d module(s)", len(detected)))
+	return nil
 }
@@ -1,10 +1,105 @@
 package autoinit
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+
+	"code-template/helpers"
+	"code-template/helpers/project"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
+)
+
+// NonInteractiveEnv names the environment variable that makes every run
+// non-interactive when set to anything but "", "0" or "false".
+const NonInteractiveEnv = "CODE_TEMPLATE_NONINTERACTIVE"
+
+// DefaultsKey is the code-template.yml section that answers prompts for
+// non-interactive runs, keyed by prompt:
+//
+//	init:
+//	  install_task: true
+//	  adopt: false
+const DefaultsKey = "init"
+
+// Prompts initialization may ask, by their key in DefaultsKey.
+const (
+	PromptInstallTask = "install_task"
+	PromptAdopt       = "adopt"
 )
 
+// Options controls how initialization talks to the user.
+type Options struct {
+	NoInput   bool            // Never read stdin; prompts take their defaults
+	AssumeYes bool            // Answer yes to every prompt; implies NoInput
+	Defaults  map[string]bool // Answers by prompt when not interactive, see LoadDefaults
+	Output    io.Writer       // Where messages go; stdout when nil
+}
+
+// Interactive reports whether prompts may read stdin.
+func (o Options) Interactive() bool {
+	return !o.NoInput && !o.AssumeYes
+}
+
+// NonInteractiveFromEnv reports whether NonInteractiveEnv asks for a
+// non-interactive run.
+func NonInteractiveFromEnv() bool {
+	switch os.Getenv(NonInteractiveEnv) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
+// InvalidDefaultError is returned when code-template.yml answers a prompt
+// with something other than true or false, or DefaultsKey isn't a section.
+type InvalidDefaultError struct {
+	Prompt string // "" when DefaultsKey itself is invalid
+	Value  any
+}
+
+func (e *InvalidDefaultError) Error() string {
+	if e.Prompt == "" {
+		return fmt.Sprintf("%s in %s is %v; want answers by prompt, such as %s: true", DefaultsKey, project.ConfigFile, e.Value, PromptAdopt)
+	}
+	return fmt.Sprintf("%s.%s in %s is %v; want true or false", DefaultsKey, e.Prompt, project.ConfigFile, e.Value)
+}
+
+// LoadDefaults reads the prompt answers the workspace root's
+// code-template.yml configures. A project not set up yet has none.
+func LoadDefaults() (map[string]bool, error) {
+	value, exists, err := yamlhelper.GetValue(filepath.Join(project.WorkspaceRoot(), project.ConfigFile), DefaultsKey)
+	if err != nil || !exists {
+		return map[string]bool{}, err
+	}
+	section, ok := value.(map[string]any)
+	if !ok {
+		return nil, &InvalidDefaultError{Value: value}
+	}
+
+	defaults := make(map[string]bool, len(section))
+	for prompt, raw := range section {
+		answer, ok := raw.(bool)
+		if !ok {
+			return nil, &InvalidDefaultError{Prompt: prompt, Value: raw}
+		}
+		defaults[prompt] = answer
+	}
+	return defaults, nil
+}
+
+// out returns where the run's messages go.
+func (o Options) out() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
 type InitError struct {
 	Step    string
 	Message string
@@ -19,75 +114,114 @@ func (e *InitError) Error() string {
 }
 
 func configExists() bool {
-	_, err := os.Stat(codeTemplateFileName)
+	_, err := os.Stat(project.Path(codeTemplateFileName))
 	return err == nil
 }
 
-func Run() error {
+// Run initializes the project on first use, offering to adopt the modules
+// it finds already set up. It runs before every command.
+func Run(ctx context.Context, modules []models.Module, opts Options) error {
 	// Always check for task - it's a hard requirement
 	if !isTaskGloballyAvailable() {
-		printError("go-task is not installed")
+		opts.printError("go-task is not installed")
 		return &InitError{
 			Step:    "task_check",
-			Message: "go-task is required but not found in PATH. Install with: go install github.com/go-task/task/v3/cmd/task@latest",
+			Message: "go-task is required but not found in PATH. Install with: " + services.TaskTool.GoInstallHint(services.TaskInstallPath),
 		}
 	}
 
 	if configExists() {
-		// Run migration for existing installations
-		if err := MigrateTasks(); err != nil {
-			printWarning("Failed to migrate tasks: " + err.Error())
-		}
 		return nil
 	}
 
-	printWelcome()
+	opts.printWelcome()
 
 	if !isGoInstalled() {
-		printError("Go compiler not found in PATH")
+		opts.printError("Go compiler not found in PATH")
 		return &InitError{
 			Step:    "go_check",
 			Message: "Go compiler not found in PATH. Please install Go first: https://go.dev/dl/",
 		}
 	}
-	printSuccess("Go compiler found")
+	opts.printSuccess("Go compiler found")
 
 	if !isTaskGloballyAvailable() {
-		if promptInstallTask() {
-			if err := installTaskGlobally(); err != nil {
-				printError("Failed to install go-task")
+		install, err := opts.promptInstallTask()
+		if err != nil {
+			return err
+		}
+		if install {
+			if err := opts.installTaskGlobally(ctx); err != nil {
+				opts.printError("Failed to install go-task")
 				return &InitError{
 					Step:    "task_install",
 					Message: "Failed to install go-task globally",
 					Err:     err,
 				}
 			}
-			printSuccess("go-task installed globally")
+			opts.printSuccess("go-task installed globally")
 		} else {
-			printWarning("Skipping go-task installation. You'll need to use ./.bin/task instead of task")
+			opts.printWarning("Skipping go-task installation. You'll need to use ./.bin/task instead of task")
 		}
 	} else {
-		printSuccess("go-task found in PATH")
+		opts.printSuccess("go-task found in PATH")
 	}
 
-	detected := detectInstalledModules()
-	if len(detected) > 0 {
-		printInfo(fmt.Sprintf("Detected %d installed module(s)", len(detected)))
-	}
+	// Detect before code-template.yml exists so nothing counts as recorded
+	detected := helpers.DetectModules(modules)
 
-	if err := createConfig(detected); err != nil {
-		printError("Failed to create code-template.yml")
+	if err := createConfig(); err != nil {
+		opts.printError("Failed to create code-template.yml")
 		return &InitError{
 			Step:    "config_create",
 			Message: "Failed to create code-template.yml",
 			Err:     err,
 		}
 	}
-	printSuccess("Created code-template.yml")
+	opts.printSuccess("Created code-template.yml")
+
+	if err := opts.adopt(ctx, detected); err != nil {
+		return err
+	}
 
-	fmt.Println()
-	printSuccess("Initialization complete!")
-	fmt.Println()
+	opts.printBlank()
+	opts.printSuccess("Initialization complete!")
+	opts.printBlank()
 
 	return nil
 }
+
+// PlanRun describes what Run would change, for a dry run: creating
+// code-template.yml and adopting the modules found, when the project isn't
+// initialized yet.
+func PlanRun(modules []models.Module) []string {
+	if configExists() {
+		return nil
+	}
+	return append([]string{"Would create " + codeTemplateFileName}, planAdopt(helpers.DetectModules(modules))...)
+}
+
+// PlanInit describes what Init would change, for a dry run.
+func PlanInit(modules []models.Module) []string {
+	if !configExists() {
+		return PlanRun(modules)
+	}
+	return planAdopt(helpers.DetectModules(modules))
+}
+
+// Init runs initialization on request. In a project that is already
+// initialized it offers to adopt modules set up since, by hand or by an
+// older code-template.
+func Init(ctx context.Context, modules []models.Module, opts Options) error {
+	existed := configExists()
+	if err := Run(ctx, modules, opts); err != nil || !existed {
+		return err
+	}
+
+	detected := helpers.DetectModules(modules)
+	if len(detected) == 0 {
+		opts.printInfo(codeTemplateFileName + " already exists and no unrecorded modules were found")
+		return nil
+	}
+	return opts.adopt(ctx, detected)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -16,42 +17,105 @@ const (
 	colorBold   = "\033[1m"
 )
 
-func printWelcome() {
-	fmt.Println()
-	fmt.Printf("%s%s=== Code Template Manager ===%s\n", colorBold, colorCyan, colorReset)
-	fmt.Println("First-time setup detected. Running initialization...")
-	fmt.Println()
+func (o Options) printWelcome() {
+	if !o.Interactive() {
+		o.printInfo("First-time setup detected. Running initialization...")
+		return
+	}
+	fmt.Fprintln(o.out())
+	fmt.Fprintf(o.out(), "%s%s=== Code Template Manager ===%s\n", colorBold, colorCyan, colorReset)
+	fmt.Fprintln(o.out(), "First-time setup detected. Running initialization...")
+	fmt.Fprintln(o.out())
 }
 
-func printSuccess(msg string) {
-	fmt.Printf("%s[OK]%s %s\n", colorGreen, colorReset, msg)
+func (o Options) printSuccess(msg string) {
+	o.printLine("ok", colorGreen, "[OK]", msg)
 }
 
-func printWarning(msg string) {
-	fmt.Printf("%s[WARN]%s %s\n", colorYellow, colorReset, msg)
+func (o Options) printWarning(msg string) {
+	o.printLine("warn", colorYellow, "[WARN]", msg)
 }
 
-func printError(msg string) {
-	fmt.Printf("%s[ERROR]%s %s\n", colorRed, colorReset, msg)
+func (o Options) printError(msg string) {
+	o.printLine("error", colorRed, "[ERROR]", msg)
 }
 
-func printInfo(msg string) {
-	fmt.Printf("%s[INFO]%s %s\n", colorCyan, colorReset, msg)
+func (o Options) printInfo(msg string) {
+	o.printLine("info", colorCyan, "[INFO]", msg)
 }
 
-func promptInstallTask() bool {
-	fmt.Println()
-	fmt.Println("go-task (task) command not found in PATH.")
-	fmt.Println("go-task is required to run module tasks (e.g., task go-lint)")
-	fmt.Println()
-	fmt.Print("Install go-task globally via 'go install'? [Y/n]: ")
+// printBlank separates sections of interactive output.
+func (o Options) printBlank() {
+	if o.Interactive() {
+		fmt.Fprintln(o.out())
+	}
+}
+
+// printLine prints msg behind a colored tag, or as a "level=ok msg=..." log
+// line that scripts can parse when running non-interactively.
+func (o Options) printLine(level, color, tag, msg string) {
+	if !o.Interactive() {
+		fmt.Fprintf(o.out(), "level=%s msg=%s\n", level, strconv.Quote(msg))
+		return
+	}
+	fmt.Fprintf(o.out(), "%s%s%s %s\n", color, tag, colorReset, msg)
+}
+
+// confirm asks a yes/no question, answering def on empty input. A
+// non-interactive run never reads stdin: it answers yes with --yes and
+// otherwise the default configured for prompt, and logs the answer. With
+// neither it fails, rather than guess.
+func (o Options) confirm(prompt, question string, def bool) (bool, error) {
+	if !o.Interactive() {
+		answer, ok := o.Defaults[prompt]
+		if o.AssumeYes {
+			answer, ok = true, true
+		}
+		if !ok {
+			return false, &InitError{
+				Step:    prompt,
+				Message: fmt.Sprintf("%q needs an answer when running non-interactively; pass --yes or --%s=yes|no, or set %s.%s in %s", question, strings.ReplaceAll(prompt, "_", "-"), DefaultsKey, prompt, codeTemplateFileName),
+			}
+		}
+		o.printInfo(fmt.Sprintf("%s %s (non-interactive)", question, yesNo(answer)))
+		return answer, nil
+	}
+
+	if answer, ok := o.Defaults[prompt]; ok {
+		def = answer
+	}
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(o.out(), "%s %s: ", question, choices)
 
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
 	if err != nil {
-		return false
+		return false, nil
 	}
 
 	input = strings.TrimSpace(strings.ToLower(input))
-	return input == "" || input == "y" || input == "yes"
+	if input == "" {
+		return def, nil
+	}
+	return input == "y" || input == "yes", nil
+}
+
+func yesNo(answer bool) string {
+	if answer {
+		return "yes"
+	}
+	return "no"
+}
+
+func (o Options) promptInstallTask() (bool, error) {
+	if o.Interactive() {
+		fmt.Fprintln(o.out())
+		fmt.Fprintln(o.out(), "go-task (task) command not found in PATH.")
+		fmt.Fprintln(o.out(), "go-task is required to run module tasks (e.g., task go-lint)")
+		fmt.Fprintln(o.out())
+	}
+	return o.confirm(PromptInstallTask, "Install go-task globally via 'go install'?", true)
 }
@@ -0,0 +1,26 @@
+package autoinit
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestConfirm_NonInteractiveUsesConfiguredAnswers(t *testing.T) {
+	var out bytes.Buffer
+	opts := Options{NoInput: true, Defaults: map[string]bool{PromptAdopt: false}, Output: &out}
+
+	if answer, err := opts.confirm(PromptAdopt, "Adopt?", true); err != nil || answer {
+		t.Errorf("expected the configured no, got %v (%v)", answer, err)
+	}
+
+	var initErr *InitError
+	if _, err := opts.confirm(PromptInstallTask, "Install?", true); !errors.As(err, &initErr) {
+		t.Errorf("expected an unanswered prompt to fail, got %v", err)
+	}
+
+	opts.AssumeYes = true
+	if answer, err := opts.confirm(PromptInstallTask, "Install?", false); err != nil || !answer {
+		t.Errorf("expected --yes to answer yes, got %v (%v)", answer, err)
+	}
+}
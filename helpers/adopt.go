@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"code-template/helpers/project"
+	"code-template/models"
+
+	yamlhelper "code-template/helpers/yaml"
+)
+
+// Detection is a module found installed in a project whose
+// code-template.yml doesn't record it.
+type Detection struct {
+	Module  models.Module
+	Version int  // Version the module is adopted at
+	Matched bool // Whether the managed files match that version as shipped
+}
+
+func (d Detection) String() string {
+	s := fmt.Sprintf("%s v%d", d.Module.GetName(), d.Version)
+	if !d.Matched {
+		s += " (modified)"
+	}
+	return s
+}
+
+// DetectModules returns the modules that recognise themselves in the project
+// but aren't in code-template.yml, in module order. Each is given the newest
+// version whose shipped files match the files on disk; when none matches, or
+// the module manages no files, it is the current version and any local
+// edits show up as drift once adopted.
+func DetectModules(modules []models.Module) []Detection {
+	var detected []Detection
+	for _, m := range modules {
+		d, ok := m.(models.Detector)
+		if !ok || GetInstalledVersion(m) > 0 || !d.Detect() {
+			continue
+		}
+		detected = append(detected, matchVersion(m))
+	}
+	return detected
+}
+
+// matchVersion finds the newest version of m whose managed files all match
+// the files on disk.
+func matchVersion(m models.Module) Detection {
+	if _, ok := m.(models.FileManager); !ok {
+		return Detection{Module: m, Version: m.GetVersion(), Matched: true}
+	}
+	vm, _ := m.(models.VersionedFileManager)
+	for v := m.GetVersion(); v >= 1; v-- {
+		if v < m.GetVersion() && (vm == nil || vm.ManagedFilesAt(v) == nil) {
+			continue // Not kept, so filesAt would give the current files
+		}
+		if filesMatch(filesAt(m, v)) {
+			return Detection{Module: m, Version: v, Matched: true}
+		}
+	}
+	return Detection{Module: m, Version: m.GetVersion()}
+}
+
+// filesMatch reports whether every file exists with exactly its content.
+func filesMatch(files []models.ManagedFile) bool {
+	for _, f := range files {
+		data, err := os.ReadFile(project.Path(f.Path))
+		if err != nil || !bytes.Equal(data, f.Content) {
+			return false
+		}
+	}
+	return true
+}
+
+// Adopt records detected modules in code-template.yml and
+// code-template.lock at their detected versions, without running any of
+// their install steps.
+func Adopt(ctx context.Context, detected []Detection) error {
+	for _, d := range detected {
+		key := d.Module.GetKey()
+		if err := yamlhelper.SetKey(codeTemplateFileName, key, d.Version); err != nil {
+			return fmt.Errorf("adopt %s: %w", key, err)
+		}
+		if err := recordLock(ctx, d.Module, d.Version); err != nil {
+			return fmt.Errorf("adopt %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"os"
+	"testing"
+
+	"code-template/models"
+)
+
+type detectableModule struct {
+	versionedModule
+}
+
+func (m *detectableModule) Detect() bool {
+	_, err := os.Stat("config.txt")
+	return err == nil
+}
+
+func TestDetectModules_InfersVersionFromContent(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("config.txt", []byte("v1\n"), 0644)
+
+	m := &detectableModule{versionedModule{
+		upgradableModule: upgradableModule{fakeModule: fakeModule{key: "mod"}, version: 2},
+		shipped: map[int][]models.ManagedFile{
+			1: {{Path: "config.txt", Content: []byte("v1\n")}},
+			2: {{Path: "config.txt", Content: []byte("v2\n")}},
+		},
+	}}
+
+	detected := DetectModules([]models.Module{m, &fakeModule{key: "other"}})
+	if len(detected) != 1 || detected[0].Version != 1 || !detected[0].Matched {
+		t.Fatalf("expected mod detected at v1, got %v", detected)
+	}
+
+	if err := Adopt(t.Context(), detected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := GetInstalledVersion(m); got != 1 {
+		t.Errorf("expected mod recorded at v1, got %d", got)
+	}
+	if len(DetectModules([]models.Module{m})) != 0 {
+		t.Error("expected an adopted module not to be detected again")
+	}
+
+	os.WriteFile("config.txt", []byte("edited\n"), 0644)
+	os.Remove(codeTemplateFileName)
+	detected = DetectModules([]models.Module{m})
+	if len(detected) != 1 || detected[0].Version != 2 || detected[0].Matched {
+		t.Errorf("expected edited files adopted at the current version, got %v", detected)
+	}
+}
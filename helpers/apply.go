@@ -0,0 +1,241 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// Ways Apply converges a module.
+const (
+	ApplyInstall = "install" // A requirement of a declared module is missing
+	ApplyRepair  = "repair"  // Declared, but IsInstalled reports false
+	ApplyUpdate  = "update"  // Declared at an older version, or its tools aren't at their pinned versions
+)
+
+// UnsupportedVersionError is returned when code-template.yml declares a
+// module at a version newer than this build of code-template ships.
+type UnsupportedVersionError struct {
+	Module    string
+	Declared  int
+	Available int
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("%s is declared at v%d but only v%d is available; update code-template", e.Module, e.Declared, e.Available)
+}
+
+// ApplyAction is one change that brings a module in line with
+// code-template.yml.
+type ApplyAction struct {
+	Module   models.Module
+	Action   string // ApplyInstall, ApplyRepair or ApplyUpdate
+	Declared int    // Version in code-template.yml; 0 for ApplyInstall
+}
+
+func (a ApplyAction) String() string {
+	switch a.Action {
+	case ApplyInstall:
+		return fmt.Sprintf("install %s v%d (required)", a.Module.GetName(), a.Module.GetVersion())
+	case ApplyUpdate:
+		if a.Declared == a.Module.GetVersion() {
+			return fmt.Sprintf("update %s v%d tools to their pinned versions", a.Module.GetName(), a.Declared)
+		}
+		return fmt.Sprintf("update %s v%d → v%d", a.Module.GetName(), a.Declared, a.Module.GetVersion())
+	}
+	s := fmt.Sprintf("repair %s v%d", a.Module.GetName(), a.Declared)
+	if a.Declared < a.Module.GetVersion() {
+		s += fmt.Sprintf(" and update to v%d", a.Module.GetVersion())
+	}
+	return s
+}
+
+// PlanApply compares the modules declared in code-template.yml with what is
+// actually installed and returns what Apply would do, requirements first.
+// A repo that is already converged yields no actions. Modified managed files
+// are left alone; they are drift, not a broken installation.
+func PlanApply(modules []models.Module) ([]ApplyAction, error) {
+	var order []models.Module
+	seen := make(map[string]bool)
+	for _, m := range modules {
+		declared := GetInstalledVersion(m)
+		if declared == 0 {
+			continue
+		}
+		if declared > m.GetVersion() {
+			return nil, &UnsupportedVersionError{Module: m.GetName(), Declared: declared, Available: m.GetVersion()}
+		}
+		closure, err := requirementOrder(modules, m)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range closure {
+			if !seen[dep.GetKey()] {
+				seen[dep.GetKey()] = true
+				order = append(order, dep)
+			}
+		}
+	}
+
+	var actions []ApplyAction
+	for _, m := range order {
+		declared := GetInstalledVersion(m)
+		switch {
+		case declared == 0:
+			actions = append(actions, ApplyAction{Module: m, Action: ApplyInstall})
+		case !m.IsInstalled():
+			actions = append(actions, ApplyAction{Module: m, Action: ApplyRepair, Declared: declared})
+		case declared < m.GetVersion(), len(OutdatedTools(m)) > 0:
+			actions = append(actions, ApplyAction{Module: m, Action: ApplyUpdate, Declared: declared})
+		}
+	}
+	return actions, nil
+}
+
+// Apply carries out one action of PlanApply.
+func Apply(ctx context.Context, a ApplyAction) error {
+	switch a.Action {
+	case ApplyInstall:
+		return InstallModule(ctx, a.Module)
+	case ApplyUpdate:
+		return updateModule(ctx, a.Module)
+	}
+
+	provider, ok := a.Module.(transaction.Provider)
+	if !ok {
+		return fmt.Errorf("module '%s' cannot describe its steps", a.Module.GetName())
+	}
+	steps := repairSteps(provider.Steps(ctx, transaction.ActionInstall))
+	if err := transaction.Run(ctx, a.Module.GetKey(), transaction.ActionInstall, steps); err != nil {
+		return err
+	}
+	if err := recordLock(ctx, a.Module, a.Declared); err != nil {
+		return err
+	}
+	if a.Declared < a.Module.GetVersion() {
+		return updateModule(ctx, a.Module)
+	}
+	return nil
+}
+
+// ApplyAll carries out the actions of PlanApply, running independent
+// modules in parallel. Returns the first failure in action order. The
+// operation is recorded in the history.
+func ApplyAll(ctx context.Context, modules []models.Module, actions []ApplyAction, progress ProgressFunc) ([]PresetResult, error) {
+	var plans []ModulePlan
+	for _, a := range actions {
+		p, err := PlanApplyAction(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, p...)
+	}
+	var results []PresetResult
+	err := recordOperation("apply", codeTemplateFileName, plans, func() error {
+		var err error
+		results, err = applyAll(ctx, modules, actions, progress)
+		return err
+	})
+	return results, err
+}
+
+func applyAll(ctx context.Context, modules []models.Module, actions []ApplyAction, progress ProgressFunc) ([]PresetResult, error) {
+	order := make([]models.Module, len(actions))
+	byKey := make(map[string]ApplyAction, len(actions))
+	for i, a := range actions {
+		order[i] = a.Module
+		byKey[a.Module.GetKey()] = a
+	}
+
+	results := runBatch(modules, order, progress, func(m models.Module) (string, error) {
+		a := byKey[m.GetKey()]
+		return applyOutcomes[a.Action], Apply(ctx, a)
+	})
+	for i, res := range results {
+		if res.Err != nil {
+			return results, &InstallError{Module: res.Module.GetName(), Action: actions[i].Action, Err: res.Err}
+		}
+	}
+	return results, nil
+}
+
+// applyOutcomes are the outcomes of each apply action.
+var applyOutcomes = map[string]string{
+	ApplyInstall: OutcomeInstalled,
+	ApplyRepair:  OutcomeRepaired,
+	ApplyUpdate:  OutcomeUpdated,
+}
+
+// PlanApplyAction returns the steps Apply would run for a, for dry runs.
+func PlanApplyAction(ctx context.Context, a ApplyAction) ([]ModulePlan, error) {
+	switch a.Action {
+	case ApplyInstall:
+		p, err := planAction(ctx, a.Module, transaction.ActionInstall)
+		if err != nil {
+			return nil, err
+		}
+		return []ModulePlan{p}, nil
+	case ApplyUpdate:
+		return PlanUpdate(ctx, a.Module)
+	}
+
+	provider, ok := a.Module.(transaction.Provider)
+	if !ok {
+		return nil, fmt.Errorf("module '%s' cannot describe its steps", a.Module.GetName())
+	}
+	plans := []ModulePlan{{
+		Module: a.Module,
+		Action: ApplyRepair,
+		Steps:  transaction.Plan(repairSteps(provider.Steps(ctx, transaction.ActionInstall))),
+	}}
+	if a.Declared < a.Module.GetVersion() {
+		steps, err := UpgradeSteps(ctx, a.Module, a.Declared, a.Module.GetVersion())
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, ModulePlan{Module: a.Module, Action: ApplyUpdate, Steps: transaction.Plan(steps)})
+	}
+	return plans, nil
+}
+
+// repairSteps adapts install steps to repair an installation that
+// code-template.yml already records. Steps that only produce files which
+// exist are skipped, so committed files and local edits survive, and so are
+// steps that only edit code-template.yml, so the declared version is kept.
+// Everything else runs: tools missing from .bin/ or PATH are reinstalled.
+func repairSteps(steps []transaction.Step) []transaction.Step {
+	repaired := make([]transaction.Step, len(steps))
+	for i, step := range steps {
+		original := step
+		step.Skip = func() bool {
+			return keepsExisting(original) || (original.Skip != nil && original.Skip())
+		}
+		repaired[i] = step
+	}
+	return repaired
+}
+
+// keepsExisting reports whether step is redundant in a repair: it writes
+// files that are all present, or edits nothing but code-template.yml.
+func keepsExisting(step transaction.Step) bool {
+	writes, onlyConfig := 0, len(step.Effects) > 0
+	for _, e := range step.Effects {
+		switch e.Kind {
+		case transaction.EffectWrite:
+			if _, err := os.Stat(project.Path(e.Target)); err != nil {
+				return false
+			}
+			writes++
+			onlyConfig = false
+		case transaction.EffectEdit:
+			onlyConfig = onlyConfig && e.Target == codeTemplateFileName
+		default:
+			onlyConfig = false
+		}
+	}
+	return writes > 0 || onlyConfig
+}
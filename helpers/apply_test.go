@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"code-template/helpers/transaction"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+// repairableModule is installed when its config file and its tool exist.
+type repairableModule struct {
+	fakeModule
+}
+
+func (m *repairableModule) IsInstalled() bool {
+	_, configErr := os.Stat("config.txt")
+	_, toolErr := os.Stat("tool")
+	return configErr == nil && toolErr == nil
+}
+
+func (m *repairableModule) Steps(context.Context, string) []transaction.Step {
+	return []transaction.Step{
+		transaction.WriteFile("write tool", "tool", []byte("bin\n")),
+		transaction.WriteFile("write config", "config.txt", []byte("shipped\n")),
+		transaction.SetKey(codeTemplateFileName, m.key, 1),
+	}
+}
+
+func TestApply_RepairsMissingToolAndKeepsFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("config.txt", []byte("edited\n"), 0644)
+
+	m := &repairableModule{fakeModule{key: "mod"}}
+	modules := []models.Module{m, &fakeModule{key: "other"}}
+
+	actions, err := PlanApply(modules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Action != ApplyRepair || actions[0].Module != m {
+		t.Fatalf("expected a repair of mod, got %v", actions)
+	}
+	if err := Apply(t.Context(), actions[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat("tool"); err != nil {
+		t.Error("expected the missing tool to be reinstalled")
+	}
+	if data, _ := os.ReadFile("config.txt"); string(data) != "edited\n" {
+		t.Errorf("expected the edited config to be kept, got %q", data)
+	}
+
+	actions, err = PlanApply(modules)
+	if err != nil || len(actions) != 0 {
+		t.Errorf("expected a converged repo to need nothing, got %v, %v", actions, err)
+	}
+}
+
+func TestPlanApply_InstallsRequirementsAndRejectsNewerVersions(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "b", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &fakeModule{key: "a"}
+	b := &fakeModule{key: "b", requires: []string{"a"}, installed: true}
+	actions, err := PlanApply([]models.Module{b, a})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Module != a || actions[0].Action != ApplyInstall {
+		t.Fatalf("expected the missing requirement a to be installed, got %v", actions)
+	}
+
+	yamlhelper.SetKey(codeTemplateFileName, "b", 2)
+	if _, err := PlanApply([]models.Module{b, a}); err == nil {
+		t.Error("expected an error for a version newer than the module ships")
+	}
+}
@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"code-template/models"
+)
+
+// Jobs is how many modules a batch operation works on at once. Modules
+// that require one another still run one after the other.
+var Jobs = 4
+
+// OutcomeRunning is reported to a ProgressFunc when a module starts.
+const OutcomeRunning = "running"
+
+// ProgressFunc is told when each module of a batch operation starts
+// (OutcomeRunning) and how it ends (one of the other outcomes). It is
+// called from the goroutine that started the batch, one call at a time.
+type ProgressFunc func(m models.Module, outcome string, err error)
+
+// runBatch runs do for every module of order, which lists requirements
+// before the modules needing them. Up to Jobs modules run at once, and a
+// module starts once every module of order it requires, directly or
+// through others, has succeeded. After a failure nothing new is started
+// and the running modules are waited for. do returns the module's outcome;
+// the results are indexed like order, with OutcomeNotAttempted for modules
+// that never started.
+func runBatch(modules, order []models.Module, progress ProgressFunc, do func(m models.Module) (string, error)) []PresetResult {
+	if progress == nil {
+		progress = func(models.Module, string, error) {}
+	}
+	jobs := max(Jobs, 1)
+
+	results := make([]PresetResult, len(order))
+	for i, m := range order {
+		results[i] = PresetResult{Module: m, Outcome: OutcomeNotAttempted}
+	}
+	waitsFor := batchRequirements(modules, order)
+
+	started := make([]bool, len(order))
+	finished := make([]bool, len(order))
+	ready := func(i int) bool {
+		for _, j := range waitsFor[i] {
+			if !finished[j] {
+				return false
+			}
+		}
+		return true
+	}
+
+	done := make(chan int)
+	running, failed := 0, false
+	for {
+		for i := 0; !failed && running < jobs && i < len(order); i++ {
+			if started[i] || !ready(i) {
+				continue
+			}
+			started[i] = true
+			running++
+			progress(order[i], OutcomeRunning, nil)
+			go func(i int) {
+				outcome, err := do(order[i])
+				if err != nil {
+					outcome = OutcomeFailed
+				}
+				results[i].Outcome, results[i].Err = outcome, err
+				done <- i
+			}(i)
+		}
+		if running == 0 {
+			return results
+		}
+
+		i := <-done
+		running--
+		finished[i] = true
+		failed = failed || results[i].Err != nil
+		progress(order[i], results[i].Outcome, results[i].Err)
+	}
+}
+
+// batchRequirements returns, for each module of order, the indexes of the
+// modules before it in order that it requires directly or transitively.
+func batchRequirements(modules, order []models.Module) [][]int {
+	index := make(map[string]int, len(order))
+	for i, m := range order {
+		index[m.GetKey()] = i
+	}
+
+	waitsFor := make([][]int, len(order))
+	for i, m := range order {
+		seen := make(map[string]bool)
+		var walk func(keys []string)
+		walk = func(keys []string) {
+			for _, key := range keys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if j, ok := index[key]; ok && j < i {
+					waitsFor[i] = append(waitsFor[i], j)
+				}
+				if dep := FindModuleByKey(modules, key); dep != nil {
+					walk(dep.GetRequires())
+				}
+			}
+		}
+		walk(m.GetRequires())
+	}
+	return waitsFor
+}
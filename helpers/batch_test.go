@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+func TestRunBatch_RunsIndependentModulesInParallel(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(jobs int) { Jobs = jobs }(Jobs)
+	Jobs = 2
+
+	a := &fakeModule{key: "a"}
+	b := &fakeModule{key: "b"}
+	c := &fakeModule{key: "c", requires: []string{"a"}}
+	modules := []models.Module{a, b, c}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var finished []string
+	results := runBatch(modules, modules, nil, func(m models.Module) (string, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		if m == c && !slices.Contains(finished, "a") {
+			t.Error("expected c to start after a finished")
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		// Shared files are written by every module at once
+		if err := yamlhelper.SetKey(codeTemplateFileName, m.GetKey(), 1); err != nil {
+			return "", err
+		}
+
+		mu.Lock()
+		running--
+		finished = append(finished, m.GetKey())
+		mu.Unlock()
+		return OutcomeInstalled, nil
+	})
+
+	if peak != 2 {
+		t.Errorf("expected 2 modules to run at once, got %d", peak)
+	}
+	for _, res := range results {
+		if res.Outcome != OutcomeInstalled || GetInstalledVersion(res.Module) != 1 {
+			t.Errorf("expected %s installed and recorded, got %s", res.Module.GetKey(), res.Outcome)
+		}
+	}
+}
+
+func TestRunBatch_StopsStartingAfterFailure(t *testing.T) {
+	defer func(jobs int) { Jobs = jobs }(Jobs)
+	Jobs = 1
+
+	a := &fakeModule{key: "a"}
+	b := &fakeModule{key: "b"}
+	modules := []models.Module{a, b}
+
+	var events []string
+	progress := func(m models.Module, outcome string, err error) {
+		events = append(events, m.GetKey()+" "+outcome)
+	}
+	results := runBatch(modules, modules, progress, func(m models.Module) (string, error) {
+		return OutcomeInstalled, errors.New("boom")
+	})
+
+	if results[0].Outcome != OutcomeFailed || results[1].Outcome != OutcomeNotAttempted {
+		t.Errorf("expected a failed and b not attempted, got %+v", results)
+	}
+	if len(events) != 2 || events[0] != "a running" || events[1] != "a failed" {
+		t.Errorf("expected progress for a only, got %v", events)
+	}
+}
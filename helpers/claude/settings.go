@@ -0,0 +1,161 @@
+package claude
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"code-template/helpers/project"
+)
+
+const (
+	dir              = ".claude"
+	settingsFileName = "settings.json"
+)
+
+// HookEntry represents a single hook command
+type HookEntry struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+}
+
+// HookConfig represents a hook configuration with optional matcher
+type HookConfig struct {
+	Matcher string      `json:"matcher,omitempty"`
+	Hooks   []HookEntry `json:"hooks"`
+}
+
+// Hook is one command hook for an event, e.g. PreToolUse.
+type Hook struct {
+	Event   string
+	Matcher string
+	Command string
+}
+
+// SettingsPath returns the full path to .claude/settings.json
+func SettingsPath() string {
+	return filepath.Join(dir, settingsFileName)
+}
+
+// ReadSettings reads .claude/settings.json and returns hooks and other fields separately
+// Returns empty structures if file doesn't exist
+func ReadSettings() (map[string][]HookConfig, map[string]any, error) {
+	data, err := os.ReadFile(project.Path(SettingsPath()))
+	if os.IsNotExist(err) {
+		return make(map[string][]HookConfig), make(map[string]any), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Handle empty file
+	if len(data) == 0 {
+		return make(map[string][]HookConfig), make(map[string]any), nil
+	}
+
+	// Parse into raw map to preserve unknown fields
+	var rawMap map[string]any
+	if err := json.Unmarshal(data, &rawMap); err != nil {
+		return nil, nil, err
+	}
+
+	// Extract and parse hooks
+	hooks := make(map[string][]HookConfig)
+	if hooksRaw, ok := rawMap["hooks"]; ok {
+		hooksBytes, err := json.Marshal(hooksRaw)
+		if err == nil {
+			json.Unmarshal(hooksBytes, &hooks)
+		}
+		delete(rawMap, "hooks")
+	}
+
+	return hooks, rawMap, nil
+}
+
+// WriteSettings writes hooks and other fields back to .claude/settings.json
+func WriteSettings(hooks map[string][]HookConfig, otherFields map[string]any) error {
+	// Ensure .claude directory exists
+	if err := os.MkdirAll(project.Path(dir), 0755); err != nil {
+		return err
+	}
+
+	// Build output map
+	outputMap := make(map[string]any)
+	for k, v := range otherFields {
+		outputMap[k] = v
+	}
+	if len(hooks) > 0 {
+		outputMap["hooks"] = hooks
+	}
+
+	data, err := json.MarshalIndent(outputMap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return project.WriteFile(SettingsPath(), data, 0644)
+}
+
+// HasCommand checks if a hook entry list runs command
+func HasCommand(hooks []HookEntry, command string) bool {
+	for _, h := range hooks {
+		if h.Type == "command" && h.Command == command {
+			return true
+		}
+	}
+	return false
+}
+
+// HasHook checks if hook is configured in settings.json
+func HasHook(hook Hook) bool {
+	hooks, _, err := ReadSettings()
+	if err != nil {
+		return false
+	}
+	for _, config := range hooks[hook.Event] {
+		if config.Matcher == hook.Matcher && HasCommand(config.Hooks, hook.Command) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddHook adds hook to settings.json unless it is already there
+func AddHook(hook Hook) error {
+	defer project.Lock(SettingsPath())()
+	if HasHook(hook) {
+		return nil
+	}
+	hooks, otherFields, err := ReadSettings()
+	if err != nil {
+		return err
+	}
+	hooks[hook.Event] = append(hooks[hook.Event], HookConfig{
+		Matcher: hook.Matcher,
+		Hooks:   []HookEntry{{Type: "command", Command: hook.Command}},
+	})
+	return WriteSettings(hooks, otherFields)
+}
+
+// RemoveHook removes hook from settings.json, preserving other hooks
+func RemoveHook(hook Hook) error {
+	defer project.Lock(SettingsPath())()
+	hooks, otherFields, err := ReadSettings()
+	if err != nil {
+		return err
+	}
+
+	var filtered []HookConfig
+	for _, config := range hooks[hook.Event] {
+		if config.Matcher != hook.Matcher || !HasCommand(config.Hooks, hook.Command) {
+			filtered = append(filtered, config)
+		}
+	}
+	if len(filtered) > 0 {
+		hooks[hook.Event] = filtered
+	} else {
+		delete(hooks, hook.Event)
+	}
+
+	return WriteSettings(hooks, otherFields)
+}
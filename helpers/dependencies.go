@@ -0,0 +1,366 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code-template/models"
+)
+
+// MissingDependencyError is returned when a module requires a key that no
+// registered module provides.
+type MissingDependencyError struct {
+	Module   string
+	Requires string
+}
+
+func (e *MissingDependencyError) Error() string {
+	return fmt.Sprintf("%s requires unknown module '%s'", e.Module, e.Requires)
+}
+
+// CycleError is returned when module requirements form a cycle.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " → ")
+}
+
+// ConflictError is returned when two modules that conflict would both be installed.
+type ConflictError struct {
+	Module        string
+	ConflictsWith string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s", e.Module, e.ConflictsWith)
+}
+
+// DependentsError is returned when uninstalling a module would break
+// installed modules that require it.
+type DependentsError struct {
+	Module     string
+	Dependents []string
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("%s is required by %s", e.Module, strings.Join(e.Dependents, ", "))
+}
+
+// InstallError is returned when one module of a batch fails.
+type InstallError struct {
+	Module string
+	Action string // "install", "uninstall", or "update"
+	Err    error
+}
+
+func (e *InstallError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %v", e.Action, e.Module, e.Err)
+}
+
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// FindModuleByKey returns the module registered under key, or nil.
+func FindModuleByKey(modules []models.Module, key string) models.Module {
+	for _, m := range modules {
+		if m.GetKey() == key {
+			return m
+		}
+	}
+	return nil
+}
+
+// ResolveInstallOrder returns the target and its transitive requirements in
+// install order (dependencies first, target last). Missing dependencies,
+// cycles and conflicts are reported before anything is installed.
+func ResolveInstallOrder(modules []models.Module, target models.Module) ([]models.Module, error) {
+	order, err := requirementOrder(modules, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConflicts(modules, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ResolveUninstallOrder returns the modules to uninstall for target.
+// If installed modules depend on target, a DependentsError is returned unless
+// cascade is set, in which case the dependents are included, ordered so that
+// every module is removed before the modules it requires (target last).
+func ResolveUninstallOrder(modules []models.Module, target models.Module, cascade bool) ([]models.Module, error) {
+	dependents := InstalledDependents(modules, target)
+	if len(dependents) > 0 && !cascade {
+		names := make([]string, 0, len(dependents))
+		for _, d := range dependents {
+			names = append(names, d.GetName())
+		}
+		return nil, &DependentsError{Module: target.GetName(), Dependents: names}
+	}
+
+	visited := make(map[string]bool)
+	var order []models.Module
+
+	var visit func(m models.Module)
+	visit = func(m models.Module) {
+		if visited[m.GetKey()] {
+			return
+		}
+		visited[m.GetKey()] = true
+		for _, d := range directDependents(modules, m) {
+			if d.IsInstalled() {
+				visit(d)
+			}
+		}
+		order = append(order, m)
+	}
+
+	visit(target)
+	return order, nil
+}
+
+// InstalledDependents returns installed modules that directly or transitively
+// require target.
+func InstalledDependents(modules []models.Module, target models.Module) []models.Module {
+	seen := make(map[string]bool)
+	var result []models.Module
+
+	queue := []models.Module{target}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, d := range directDependents(modules, current) {
+			if seen[d.GetKey()] || !d.IsInstalled() {
+				continue
+			}
+			seen[d.GetKey()] = true
+			result = append(result, d)
+			queue = append(queue, d)
+		}
+	}
+	return result
+}
+
+// ValidateDependencies checks that every module's requirements exist and that
+// the requirement graph contains no cycles.
+func ValidateDependencies(modules []models.Module) error {
+	for _, m := range modules {
+		if _, err := requirementOrder(modules, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InstallWithDependencies installs target along with any requirements that
+// are not yet installed, in dependency order, running independent
+// requirements in parallel. An outdated target is updated, after installing
+// any requirements it gained, and recorded in the history as an update.
+// Every install and update goes through here, so requirements and conflicts
+// are checked in one place. Returns the modules that were installed or
+// updated.
+func InstallWithDependencies(ctx context.Context, modules []models.Module, target models.Module, progress ProgressFunc) ([]models.Module, error) {
+	order, err := ResolveInstallOrder(modules, target)
+	if err != nil {
+		return nil, err
+	}
+	action := "install"
+	if GetModuleState(target) == StateOutdated {
+		action = "update"
+	}
+	plans, err := PlanInstall(ctx, modules, target)
+	if err != nil {
+		return nil, err
+	}
+	var done []models.Module
+	err = recordOperation(action, target.GetKey(), plans, func() error {
+		var err error
+		done, err = installWithDependencies(ctx, modules, target, order, progress)
+		return err
+	})
+	return done, err
+}
+
+func installWithDependencies(ctx context.Context, modules []models.Module, target models.Module, order []models.Module, progress ProgressFunc) ([]models.Module, error) {
+	var pending []models.Module
+	update := false
+	for _, m := range order {
+		switch GetModuleState(m) {
+		case StateNotInstalled:
+			pending = append(pending, m)
+		case StateOutdated:
+			if m == target {
+				pending = append(pending, m)
+				update = true
+			}
+		case StateUpToDate, StateModified:
+		}
+	}
+
+	results := runBatch(modules, pending, progress, func(m models.Module) (string, error) {
+		if update && m == target {
+			return OutcomeUpdated, updateModule(ctx, m)
+		}
+		return OutcomeInstalled, InstallModule(ctx, m)
+	})
+
+	var done []models.Module
+	var failure error
+	for _, res := range results {
+		switch {
+		case res.Err != nil && failure == nil:
+			action := "install"
+			if update && res.Module == target {
+				action = "update"
+			}
+			failure = &InstallError{Module: res.Module.GetName(), Action: action, Err: res.Err}
+		case res.Err == nil && res.Outcome != OutcomeNotAttempted:
+			done = append(done, res.Module)
+		}
+	}
+	return done, failure
+}
+
+// UninstallWithDependents uninstalls target. With cascade set, installed
+// dependents are uninstalled first; otherwise they block the uninstall.
+// Returns the modules that were uninstalled. The operation is recorded in
+// the history.
+func UninstallWithDependents(ctx context.Context, modules []models.Module, target models.Module, cascade bool) ([]models.Module, error) {
+	order, err := ResolveUninstallOrder(modules, target, cascade)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := PlanUninstall(ctx, modules, target, cascade)
+	if err != nil {
+		return nil, err
+	}
+	var done []models.Module
+	err = recordOperation("uninstall", target.GetKey(), plans, func() error {
+		for _, m := range order {
+			if err := UninstallModule(ctx, m); err != nil {
+				return &InstallError{Module: m.GetName(), Action: "uninstall", Err: err}
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// ModuleNames returns the display names of modules.
+func ModuleNames(modules []models.Module) []string {
+	names := make([]string, 0, len(modules))
+	for _, m := range modules {
+		names = append(names, m.GetName())
+	}
+	return names
+}
+
+// requirementOrder walks the requirement graph depth-first and returns the
+// target's closure in topological order.
+func requirementOrder(modules []models.Module, target models.Module) ([]models.Module, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	var order []models.Module
+	var stack []string
+
+	var visit func(m models.Module) error
+	visit = func(m models.Module) error {
+		key := m.GetKey()
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			return &CycleError{Path: cyclePath(stack, key)}
+		}
+
+		state[key] = visiting
+		stack = append(stack, key)
+		for _, req := range m.GetRequires() {
+			dep := FindModuleByKey(modules, req)
+			if dep == nil {
+				return &MissingDependencyError{Module: key, Requires: req}
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = visited
+		order = append(order, m)
+		return nil
+	}
+
+	if err := visit(target); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// checkConflicts reports a conflict between modules in the install set, or
+// between the install set and modules that are already installed.
+func checkConflicts(modules []models.Module, installSet []models.Module) error {
+	inSet := make(map[string]bool)
+	for _, m := range installSet {
+		inSet[m.GetKey()] = true
+	}
+
+	for _, m := range installSet {
+		for _, key := range m.GetConflicts() {
+			other := FindModuleByKey(modules, key)
+			if other == nil {
+				continue
+			}
+			if inSet[key] || other.IsInstalled() {
+				return &ConflictError{Module: m.GetName(), ConflictsWith: other.GetName()}
+			}
+		}
+	}
+
+	// Conflicts are symmetric: an installed module may declare the conflict.
+	for _, other := range modules {
+		if inSet[other.GetKey()] || !other.IsInstalled() {
+			continue
+		}
+		for _, key := range other.GetConflicts() {
+			if inSet[key] {
+				m := FindModuleByKey(modules, key)
+				return &ConflictError{Module: m.GetName(), ConflictsWith: other.GetName()}
+			}
+		}
+	}
+	return nil
+}
+
+func directDependents(modules []models.Module, target models.Module) []models.Module {
+	var result []models.Module
+	for _, m := range modules {
+		for _, req := range m.GetRequires() {
+			if req == target.GetKey() {
+				result = append(result, m)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func cyclePath(stack []string, key string) []string {
+	for i, k := range stack {
+		if k == key {
+			path := append([]string{}, stack[i:]...)
+			return append(path, key)
+		}
+	}
+	return []string{key}
+}
@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"code-template/helpers/transaction"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+type fakeModule struct {
+	key       string
+	requires  []string
+	conflicts []string
+	installed bool
+}
+
+func (m *fakeModule) GetName() string                 { return m.key }
+func (m *fakeModule) GetCategory() string             { return "test" }
+func (m *fakeModule) GetPath() string                 { return "test/" + m.key }
+func (m *fakeModule) GetVersion() int                 { return 1 }
+func (m *fakeModule) GetKey() string                  { return m.key }
+func (m *fakeModule) GetRequires() []string           { return m.requires }
+func (m *fakeModule) GetConflicts() []string          { return m.conflicts }
+func (m *fakeModule) IsInstalled() bool               { return m.installed }
+func (m *fakeModule) Install(context.Context) error   { m.installed = true; return nil }
+func (m *fakeModule) Uninstall(context.Context) error { m.installed = false; return nil }
+
+func keys(modules []models.Module) []string {
+	result := make([]string, 0, len(modules))
+	for _, m := range modules {
+		result = append(result, m.GetKey())
+	}
+	return result
+}
+
+func TestResolveInstallOrder_DependenciesFirst(t *testing.T) {
+	a := &fakeModule{key: "a"}
+	b := &fakeModule{key: "b", requires: []string{"a"}}
+	c := &fakeModule{key: "c", requires: []string{"b", "a"}}
+	modules := []models.Module{c, b, a}
+
+	order, err := ResolveInstallOrder(modules, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := keys(order)
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestResolveInstallOrder_ReportsCycle(t *testing.T) {
+	a := &fakeModule{key: "a", requires: []string{"b"}}
+	b := &fakeModule{key: "b", requires: []string{"a"}}
+
+	_, err := ResolveInstallOrder([]models.Module{a, b}, a)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+}
+
+func TestResolveInstallOrder_ReportsConflictWithInstalled(t *testing.T) {
+	a := &fakeModule{key: "a", installed: true}
+	b := &fakeModule{key: "b", conflicts: []string{"a"}}
+
+	_, err := ResolveInstallOrder([]models.Module{a, b}, b)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+}
+
+func TestResolveUninstallOrder_BlocksOrCascades(t *testing.T) {
+	a := &fakeModule{key: "a", installed: true}
+	b := &fakeModule{key: "b", requires: []string{"a"}, installed: true}
+	modules := []models.Module{a, b}
+
+	_, err := ResolveUninstallOrder(modules, a, false)
+	var dependents *DependentsError
+	if !errors.As(err, &dependents) {
+		t.Fatalf("expected DependentsError, got %v", err)
+	}
+
+	order, err := ResolveUninstallOrder(modules, a, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := keys(order)
+	if len(got) != 2 || got[0] != "b" || got[1] != "a" {
+		t.Fatalf("expected [b a], got %v", got)
+	}
+}
+
+func TestInstallWithDependencies_UpdateInstallsNewRequirements(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &steppedModule{fakeModule{key: "req"}}
+	m := &upgradableModule{
+		fakeModule: fakeModule{key: "mod", requires: []string{"req"}, installed: true},
+		version:    2,
+		hops:       map[int][]transaction.Step{1: nil},
+	}
+	done, err := InstallWithDependencies(t.Context(), []models.Module{m, req}, m, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !req.IsInstalled() || len(done) != 2 {
+		t.Errorf("expected req to be installed along with the update, got %v", keys(done))
+	}
+	if v := GetInstalledVersion(m); v != 2 {
+		t.Errorf("expected installed version 2, got %d", v)
+	}
+	entries, _ := ReadHistory()
+	if len(entries) != 1 || entries[0].Action != "update" {
+		t.Errorf("expected the update in the history, got %v", entries)
+	}
+}
+
+func TestInstallWithDependencies_ReturnsPlanningErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// fakeModule can't describe its steps, so the operation can't be planned
+	m := &fakeModule{key: "mod"}
+	if _, err := InstallWithDependencies(t.Context(), []models.Module{m}, m, nil); err == nil {
+		t.Fatal("expected the planning error")
+	}
+	if m.installed {
+		t.Error("expected nothing to be installed without a plan")
+	}
+}
@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"sort"
+
+	"code-template/models"
+)
+
+// ModuleInfo describes a module and its state in the project for
+// machine-readable output.
+type ModuleInfo struct {
+	Name             string     `json:"name" yaml:"name"`
+	Key              string     `json:"key" yaml:"key"`
+	Path             string     `json:"path" yaml:"path"`
+	Category         string     `json:"category" yaml:"category"`
+	Version          int        `json:"version" yaml:"version"`                     // Available version
+	InstalledVersion int        `json:"installed_version" yaml:"installed_version"` // 0 when not installed
+	State            string     `json:"state" yaml:"state"`                         // ModuleState.String()
+	Requires         []string   `json:"requires" yaml:"requires"`
+	Conflicts        []string   `json:"conflicts" yaml:"conflicts"`
+	Files            []FileInfo `json:"files" yaml:"files"`                                       // Managed files, sorted by path
+	OutdatedTools    []string   `json:"outdated_tools,omitempty" yaml:"outdated_tools,omitempty"` // Tools not at their pinned versions
+}
+
+// FileInfo is one managed file of a module. State is "ok", "modified" or
+// "missing".
+type FileInfo struct {
+	Path  string `json:"path" yaml:"path"`
+	State string `json:"state" yaml:"state"`
+}
+
+// PresetInfo describes a preset for machine-readable output.
+type PresetInfo struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	Modules     []string `json:"modules" yaml:"modules"`
+	Installed   bool     `json:"installed" yaml:"installed"`
+}
+
+// ModuleList is the machine-readable form of the module list.
+type ModuleList struct {
+	Modules []ModuleInfo `json:"modules" yaml:"modules"`
+	Presets []PresetInfo `json:"presets" yaml:"presets"`
+}
+
+// TreeNodeInfo is the machine-readable form of a tree node.
+type TreeNodeInfo struct {
+	ID       string         `json:"id" yaml:"id"`
+	Name     string         `json:"name" yaml:"name"`
+	Type     string         `json:"type" yaml:"type"` // "category", "module", "preset" or "member"
+	Path     string         `json:"path,omitempty" yaml:"path,omitempty"`
+	Children []TreeNodeInfo `json:"children" yaml:"children"`
+}
+
+// DescribeModule returns m's description and current state.
+func DescribeModule(m models.Module) ModuleInfo {
+	state := GetModuleState(m)
+	info := ModuleInfo{
+		Name:             m.GetName(),
+		Key:              m.GetKey(),
+		Path:             m.GetPath(),
+		Category:         m.GetCategory(),
+		Version:          m.GetVersion(),
+		InstalledVersion: GetInstalledVersion(m),
+		State:            state.String(),
+		Requires:         sortedCopy(m.GetRequires()),
+		Conflicts:        sortedCopy(m.GetConflicts()),
+		Files:            []FileInfo{},
+	}
+	if state != StateNotInstalled {
+		info.OutdatedTools = OutdatedTools(m)
+	}
+
+	drifted, _ := DetectDrift(m)
+	states := make(map[string]string)
+	for _, f := range drifted {
+		states[f.Path] = "modified"
+		if f.Missing {
+			states[f.Path] = "missing"
+		}
+	}
+	for _, f := range shippedFiles(m) {
+		fileState := "ok"
+		if s, ok := states[f.Path]; ok {
+			fileState = s
+		}
+		info.Files = append(info.Files, FileInfo{Path: f.Path, State: fileState})
+	}
+	sort.Slice(info.Files, func(i, j int) bool { return info.Files[i].Path < info.Files[j].Path })
+	return info
+}
+
+// DescribeModules describes modules sorted by category, then name. With
+// installedOnly set, modules that aren't installed are left out.
+func DescribeModules(modules []models.Module, installedOnly bool) []ModuleInfo {
+	infos := []ModuleInfo{}
+	for _, m := range SortModules(modules) {
+		info := DescribeModule(m)
+		if installedOnly && info.State == StateNotInstalled.String() {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// DescribeList returns every module, sorted as by DescribeModules, and
+// every preset, sorted by name.
+func DescribeList(modules []models.Module, presets []models.Preset) ModuleList {
+	list := ModuleList{
+		Modules: DescribeModules(modules, false),
+		Presets: []PresetInfo{},
+	}
+	for _, p := range presets {
+		list.Presets = append(list.Presets, PresetInfo{
+			Name:        p.Name,
+			Description: p.Description,
+			Modules:     append([]string{}, p.Modules...),
+			Installed:   IsPresetInstalled(modules, p),
+		})
+	}
+	sort.Slice(list.Presets, func(i, j int) bool { return list.Presets[i].Name < list.Presets[j].Name })
+	return list
+}
+
+// DescribeTree converts tree roots into their machine-readable form,
+// keeping the tree's order.
+func DescribeTree(nodes []*models.TreeNode) []TreeNodeInfo {
+	infos := []TreeNodeInfo{}
+	for _, node := range nodes {
+		info := TreeNodeInfo{
+			ID:       node.ID,
+			Name:     node.Name,
+			Type:     nodeTypeName(node.Type),
+			Children: DescribeTree(node.Children),
+		}
+		if node.Module != nil {
+			info.Path = node.Module.GetPath()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SortModules returns modules sorted by category, then name.
+func SortModules(modules []models.Module) []models.Module {
+	sorted := append([]models.Module{}, modules...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].GetCategory() != sorted[j].GetCategory() {
+			return sorted[i].GetCategory() < sorted[j].GetCategory()
+		}
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+	return sorted
+}
+
+func nodeTypeName(t models.NodeType) string {
+	switch t {
+	case models.NodeModule:
+		return "module"
+	case models.NodePreset:
+		return "preset"
+	case models.NodeMember:
+		return "member"
+	}
+	return "category"
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
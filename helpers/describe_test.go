@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"os"
+	"testing"
+
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+type categorizedModule struct {
+	fakeModule
+	category string
+}
+
+func (m *categorizedModule) GetCategory() string { return m.category }
+
+func TestDescribeList_SortsByCategoryThenName(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	modules := []models.Module{
+		&categorizedModule{fakeModule{key: "zeta"}, "linting"},
+		&categorizedModule{fakeModule{key: "beta"}, "tasks"},
+		&categorizedModule{fakeModule{key: "alpha"}, "tasks"},
+		&categorizedModule{fakeModule{key: "omega"}, "claude"},
+	}
+	presets := []models.Preset{{Name: "web"}, {Name: "go"}}
+
+	list := DescribeList(modules, presets)
+	var got []string
+	for _, info := range list.Modules {
+		got = append(got, info.Key)
+	}
+	want := []string{"omega", "zeta", "alpha", "beta"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if list.Presets[0].Name != "go" || list.Presets[1].Name != "web" {
+		t.Errorf("expected presets sorted by name, got %v", list.Presets)
+	}
+}
+
+func TestDescribeModule_ReportsStateAndFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("b.txt", []byte("edited\n"), 0644)
+
+	m := &versionedModule{
+		upgradableModule: upgradableModule{fakeModule: fakeModule{key: "mod", installed: true}, version: 2},
+		shipped: map[int][]models.ManagedFile{
+			1: {{Path: "b.txt", Content: []byte("b\n")}, {Path: "a.txt", Content: []byte("a\n")}},
+		},
+	}
+
+	info := DescribeModule(m)
+	if info.State != "outdated" || info.Version != 2 || info.InstalledVersion != 1 {
+		t.Errorf("expected outdated v1 of v2, got %s v%d of v%d", info.State, info.InstalledVersion, info.Version)
+	}
+	if len(info.Files) != 2 || info.Files[0] != (FileInfo{Path: "a.txt", State: "missing"}) ||
+		info.Files[1] != (FileInfo{Path: "b.txt", State: "modified"}) {
+		t.Errorf("expected files sorted with their states, got %v", info.Files)
+	}
+}
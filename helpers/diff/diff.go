@@ -0,0 +1,147 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpKind is the kind of a single line edit.
+type OpKind int
+
+const (
+	Equal  OpKind = iota // Line is present in both inputs
+	Delete               // Line is only in the old input
+	Insert               // Line is only in the new input
+)
+
+// Op is one line of an edit script turning a into b.
+type Op struct {
+	Kind OpKind
+	Line string
+	A, B int // 0-based positions in a and b before this op
+}
+
+// context is the number of unchanged lines shown around each hunk.
+const context = 3
+
+// SplitLines splits data into lines without their trailing newlines.
+func SplitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// Compute returns a minimal edit script turning a into b, based on the
+// longest common subsequence of lines.
+func Compute(a, b []string) []Op {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []Op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, Op{Kind: Equal, Line: a[i], A: i, B: j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, Op{Kind: Delete, Line: a[i], A: i, B: j})
+			i++
+		default:
+			ops = append(ops, Op{Kind: Insert, Line: b[j], A: i, B: j})
+			j++
+		}
+	}
+	return ops
+}
+
+// Unified returns a unified diff from a to b, or "" if they are identical.
+func Unified(fromName, toName string, a, b []byte) string {
+	ops := Compute(SplitLines(a), SplitLines(b))
+	hunks := group(ops)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks {
+		lines := ops[h[0]:h[1]]
+		aStart, bStart := lines[0].A, lines[0].B
+		aCount, bCount := 0, 0
+		for _, op := range lines {
+			if op.Kind != Insert {
+				aCount++
+			}
+			if op.Kind != Delete {
+				bCount++
+			}
+		}
+		// Ranges are 1-based unless empty
+		if aCount > 0 {
+			aStart++
+		}
+		if bCount > 0 {
+			bStart++
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range lines {
+			switch op.Kind {
+			case Equal:
+				sb.WriteString(" ")
+			case Delete:
+				sb.WriteString("-")
+			case Insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(op.Line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// group returns [start, end) op ranges for each hunk, merging changes that
+// are close enough for their context lines to overlap.
+func group(ops []Op) [][2]int {
+	var hunks [][2]int
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].Kind != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == Equal {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		hunks = append(hunks, [2]int{start, end})
+		i = end
+	}
+	return hunks
+}
@@ -0,0 +1,40 @@
+package diff
+
+import "testing"
+
+func TestUnified_Identical(t *testing.T) {
+	if got := Unified("a", "b", []byte("x\ny\n"), []byte("x\ny\n")); got != "" {
+		t.Errorf("expected no diff, got %q", got)
+	}
+}
+
+func TestUnified_SingleChange(t *testing.T) {
+	a := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	b := []byte("1\n2\n3\n4\nfive\n6\n7\n8\n9\n")
+
+	want := "--- a\n+++ b\n" +
+		"@@ -2,7 +2,7 @@\n" +
+		" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got := Unified("a", "b", a, b); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	a := []byte("a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n")
+	b := []byte("A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n")
+
+	want := "--- a\n+++ b\n" +
+		"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+		"@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n"
+	if got := Unified("a", "b", a, b); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_FromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n"
+	if got := Unified("a", "b", nil, []byte("x\n")); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"code-template/models"
+	"code-template/services"
+)
+
+// selfName is the owner of the requirements of code-template itself.
+const selfName = "code-template"
+
+// RequirementCheck is the outcome of checking one requirement of a module.
+type RequirementCheck struct {
+	Module      string `json:"module" yaml:"module"` // Module name, or "code-template" for its own requirements
+	InUse       bool   `json:"in_use" yaml:"in_use"` // The module is installed, so the requirement must be met
+	Requirement string `json:"requirement" yaml:"requirement"`
+	Found       bool   `json:"found" yaml:"found"`
+	Version     string `json:"version" yaml:"version"` // "" when unknown or missing
+	Hint        string `json:"hint" yaml:"hint"`
+}
+
+// Failed reports whether c is missing something an installed module needs.
+func (c RequirementCheck) Failed() bool {
+	return c.InUse && !c.Found
+}
+
+// Diagnose checks the requirements of code-template itself and of every
+// module that declares them, in module order. A module counts as installed
+// when code-template.yml records it, even if a missing tool makes
+// IsInstalled false. Requirements shared by several modules are checked
+// once.
+func Diagnose(modules []models.Module) []RequirementCheck {
+	type result struct {
+		found   bool
+		version string
+	}
+	results := make(map[string]result)
+	check := func(owner string, inUse bool, r models.Requirement) RequirementCheck {
+		res, ok := results[r.Name]
+		if !ok {
+			res.found = r.Found()
+			if res.found && r.Version != nil {
+				res.version = r.Version()
+			}
+			results[r.Name] = res
+		}
+		return RequirementCheck{
+			Module:      owner,
+			InUse:       inUse,
+			Requirement: r.Name,
+			Found:       res.found,
+			Version:     res.version,
+			Hint:        r.Hint,
+		}
+	}
+
+	checks := []RequirementCheck{check(selfName, true, services.TaskRequirement)}
+	for _, m := range modules {
+		provider, ok := m.(models.RequirementProvider)
+		if !ok {
+			continue
+		}
+		inUse := GetInstalledVersion(m) > 0
+		for _, r := range provider.Requirements() {
+			checks = append(checks, check(m.GetName(), inUse, r))
+		}
+	}
+	return checks
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"os"
+	"testing"
+
+	"code-template/models"
+)
+
+type requiringModule struct {
+	fakeModule
+	requirements []models.Requirement
+}
+
+func (m *requiringModule) Requirements() []models.Requirement { return m.requirements }
+
+func TestDiagnose_FailsOnlyForInstalledModules(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile(codeTemplateFileName, []byte("used: 1\n"), 0644)
+
+	calls := 0
+	missing := models.Requirement{
+		Name:  "missing-tool",
+		Hint:  "install it",
+		Found: func() bool { calls++; return false },
+	}
+	modules := []models.Module{
+		&requiringModule{fakeModule: fakeModule{key: "used"}, requirements: []models.Requirement{missing}},
+		&requiringModule{fakeModule: fakeModule{key: "unused"}, requirements: []models.Requirement{missing}},
+		&fakeModule{key: "plain"},
+	}
+
+	var failed []string
+	for _, c := range Diagnose(modules) {
+		if c.Requirement == "missing-tool" && c.Failed() {
+			failed = append(failed, c.Module)
+		}
+		if c.Module == "plain" {
+			t.Errorf("module without requirements listed: %+v", c)
+		}
+	}
+	if len(failed) != 1 || failed[0] != "used" {
+		t.Errorf("expected only the installed module to fail, got %v", failed)
+	}
+	if calls != 1 {
+		t.Errorf("expected a shared requirement to be checked once, got %d", calls)
+	}
+}
@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code-template/helpers/diff"
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/models"
+)
+
+// DriftedFile is a managed file that no longer matches its embedded content.
+type DriftedFile struct {
+	Path     string
+	Expected []byte // Embedded content
+	Actual   []byte // Content on disk; empty if Missing
+	Missing  bool
+}
+
+// Diff returns a unified diff from the embedded content to the file on disk.
+func (f DriftedFile) Diff() string {
+	to := f.Path
+	if f.Missing {
+		to = "/dev/null"
+	}
+	return diff.Unified(filepath.Join("embedded", f.Path), to, f.Expected, f.Actual)
+}
+
+// DetectDrift compares every managed file of m against the content the
+// module shipped in the installed version. A file is unmodified if it matches
+// either that content or the hash recorded in code-template.lock. Modules
+// that don't manage files never drift.
+func DetectDrift(m models.Module) ([]DriftedFile, error) {
+	if _, ok := m.(models.FileManager); !ok {
+		return nil, nil
+	}
+	locked, _ := lockfile.Get(m.GetKey())
+
+	var drifted []DriftedFile
+	for _, f := range shippedFiles(m) {
+		data, err := os.ReadFile(project.Path(f.Path))
+		if errors.Is(err, fs.ErrNotExist) {
+			drifted = append(drifted, DriftedFile{Path: f.Path, Expected: f.Content, Missing: true})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(data, f.Content) {
+			continue
+		}
+		if lf, ok := locked.File(f.Path); ok && lf.SHA256 == lockfile.Hash(data) {
+			continue
+		}
+		drifted = append(drifted, DriftedFile{Path: f.Path, Expected: f.Content, Actual: data})
+	}
+	return drifted, nil
+}
+
+// shippedFiles returns m's managed files as shipped in the installed
+// version, falling back to the current content if the module doesn't
+// keep that version.
+func shippedFiles(m models.Module) []models.ManagedFile {
+	return filesAt(m, GetInstalledVersion(m))
+}
+
+// filesAt returns m's managed files as shipped in version, falling back to
+// the current content if the module doesn't keep that version, or nil if it
+// manages no files.
+func filesAt(m models.Module, version int) []models.ManagedFile {
+	if vm, ok := m.(models.VersionedFileManager); ok {
+		if files := vm.ManagedFilesAt(version); files != nil {
+			return files
+		}
+	}
+	if fm, ok := m.(models.FileManager); ok {
+		return fm.ManagedFiles()
+	}
+	return nil
+}
+
+// HasDrift returns true if any managed file of m was edited or removed.
+func HasDrift(m models.Module) bool {
+	drifted, err := DetectDrift(m)
+	return err == nil && len(drifted) > 0
+}
+
+// RestoreFiles overwrites drifted files with their embedded content.
+// Restoring is idempotent, so a partial failure can simply be retried.
+func RestoreFiles(files []DriftedFile) error {
+	var errs []error
+	for _, f := range files {
+		path := project.Path(f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.WriteFile(path, f.Expected, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FormatDrift renders the diffs of drifted files one after another.
+func FormatDrift(files []DriftedFile) string {
+	var sb strings.Builder
+	for _, f := range files {
+		sb.WriteString(f.Diff())
+	}
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"code-template/models"
+)
+
+type managedModule struct {
+	fakeModule
+	files []models.ManagedFile
+}
+
+func (m *managedModule) ManagedFiles() []models.ManagedFile { return m.files }
+
+func TestDetectDrift(t *testing.T) {
+	t.Chdir(t.TempDir())
+	m := &managedModule{
+		fakeModule: fakeModule{key: "mod", installed: true},
+		files: []models.ManagedFile{
+			{Path: "same.yml", Content: []byte("a: 1\n")},
+			{Path: "edited.yml", Content: []byte("a: 1\n")},
+			{Path: "missing.yml", Content: []byte("a: 1\n")},
+		},
+	}
+	os.WriteFile("same.yml", []byte("a: 1\n"), 0644)
+	os.WriteFile("edited.yml", []byte("a: 2\n"), 0644)
+
+	drifted, err := DetectDrift(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(drifted) != 2 || drifted[0].Path != "edited.yml" || !drifted[1].Missing {
+		t.Fatalf("expected edited.yml and missing.yml to drift, got %+v", drifted)
+	}
+	if d := drifted[0].Diff(); !strings.Contains(d, "-a: 1\n+a: 2\n") {
+		t.Errorf("unexpected diff:\n%s", d)
+	}
+
+	if err := RestoreFiles(drifted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if HasDrift(m) {
+		t.Error("expected no drift after restore")
+	}
+}
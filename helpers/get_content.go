@@ -1,34 +1,126 @@
 package helpers
 
 import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
 	"code-template/models"
-	frontenddesign "code-template/modules/claude/skills/frontend-design"
 	getshitdone "code-template/modules/claude/workflow/get_shit_done"
 	tddguard "code-template/modules/claude/workflow/tdd_guard"
 	gotstwwailsreact "code-template/modules/language/go/go_ts_tw_wails_react"
 	golangcilint "code-template/modules/linting/go/golangci_lint"
-	eslint "code-template/modules/linting/typescript/eslint"
-	golinttask "code-template/modules/tasks/go/go_lint_task"
-	gotesttask "code-template/modules/tasks/go/go_test_task"
-	tslinttask "code-template/modules/tasks/typescript/ts_lint_task"
-	tstesttask "code-template/modules/tasks/typescript/ts_test_task"
-	wailsdev "code-template/modules/tasks/wails/wails_dev"
+	"code-template/modules/manifest"
+	"code-template/modules/plugin"
 )
 
-// GetModules returns the list of all available modules.
+// GetModules returns the list of all built-in modules: the Go modules and
+// those declared by the embedded manifests.
 func GetModules() []models.Module {
-	return []models.Module{
-		frontenddesign.Module,
+	modules := []models.Module{
 		getshitdone.Module,
 		tddguard.Module,
 		gotstwwailsreact.Module,
 		golangcilint.Module,
-		eslint.Module,
-		golinttask.Module,
-		gotesttask.Module,
-		tslinttask.Module,
-		tstesttask.Module,
-		wailsdev.Module,
+	}
+
+	builtin, err := manifest.Builtin()
+	if err != nil {
+		// The embedded manifests are tested; this is a build error
+		panic(err)
+	}
+	for _, m := range builtin {
+		modules = append(modules, m)
+	}
+	return modules
+}
+
+// DuplicateModuleError reports a project manifest whose name or key is
+// already taken.
+type DuplicateModuleError struct {
+	Name string
+}
+
+func (e *DuplicateModuleError) Error() string {
+	return fmt.Sprintf("module '%s' is declared more than once", e.Name)
+}
+
+// LoadModules returns the built-in modules plus those declared by manifests
+// in .code-template/modules/ and the plugins in .code-template/plugins/ and
+// on PATH. A plugin that fails to describe itself, takes a name or key
+// already taken or has requirements that can't be resolved is left out,
+// with an error naming it in skipped: a broken third-party executable
+// shouldn't stop code-template, while a broken project manifest should.
+func LoadModules() (modules []models.Module, skipped []error, err error) {
+	modules = GetModules()
+
+	local, err := manifest.Local()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range local {
+		if modules, err = addModule(modules, m); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	plugins, skipped := plugin.Load()
+	var loaded []*plugin.Module
+	for _, m := range plugins {
+		added, err := addModule(modules, m)
+		if err != nil {
+			skipped = append(skipped, skippedPlugin(m, err))
+			continue
+		}
+		modules, loaded = added, append(loaded, m)
+	}
+
+	// Dropping a plugin can break the requirements of another
+	for dropped := true; dropped; {
+		dropped = false
+		for i, m := range loaded {
+			if _, err := requirementOrder(modules, m); err != nil {
+				skipped = append(skipped, skippedPlugin(m, err))
+				modules = slices.DeleteFunc(modules, func(other models.Module) bool { return other == models.Module(m) })
+				loaded = slices.Delete(loaded, i, i+1)
+				dropped = true
+				break
+			}
+		}
+	}
+	return modules, skipped, nil
+}
+
+// skippedPlugin reports why LoadModules left plugin m out.
+func skippedPlugin(m *plugin.Module, err error) error {
+	return &plugin.PluginError{Plugin: filepath.Base(m.Executable()), Operation: plugin.OpDescribe, Err: err}
+}
+
+// addModule appends m unless its name or key is already taken.
+func addModule(modules []models.Module, m models.Module) ([]models.Module, error) {
+	if FindModuleByKey(modules, m.GetKey()) != nil {
+		return nil, &DuplicateModuleError{Name: m.GetKey()}
+	}
+	if findModuleByName(modules, m.GetName()) != nil {
+		return nil, &DuplicateModuleError{Name: m.GetName()}
+	}
+	return append(modules, m), nil
+}
+
+// GetPresets returns the built-in presets. Projects can add their own, or
+// override these by name, under "presets" in code-template.yml.
+func GetPresets() []models.Preset {
+	return []models.Preset{
+		{
+			Name:        "go",
+			Description: "Go linting and test tasks",
+			Modules:     []string{"golangci", "go-lint", "go-test"},
+		},
+		{
+			Name:        "wails",
+			Description: "Wails + React + TypeScript app with linting, tests and dev task",
+			Modules:     []string{"go-ts-tw-wails-react", "eslint", "ts-lint", "ts-test", "dev"},
+		},
 	}
 }
 
@@ -1,6 +1,14 @@
 package helpers
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"code-template/modules/plugin"
+)
 
 func TestGetContent_DoesNotPanic(t *testing.T) {
 	// This test will fail with "panic: assignment to entry in nil map"
@@ -11,3 +19,39 @@ func TestGetContent_DoesNotPanic(t *testing.T) {
 		t.Error("GetContent() returned nil")
 	}
 }
+
+func TestLoadModules_SkipsBrokenPlugins(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+	os.MkdirAll(plugin.LocalDir, 0755)
+	plugins := map[string]string{
+		"broken":    "exit 1",
+		"duplicate": `echo '{"protocol": 1, "module": {"name": "dup", "key": "golangci", "path": "test/dup", "version": 1}}'`,
+		"orphan":    `echo '{"protocol": 1, "module": {"name": "orphan", "path": "test/orphan", "version": 1, "requires": ["missing"]}}'`,
+		"good":      `echo '{"protocol": 1, "module": {"name": "good", "path": "test/good", "version": 1}}'`,
+	}
+	for name, body := range plugins {
+		script := "#!/bin/sh\ncat > /dev/null\n" + body + "\n"
+		os.WriteFile(filepath.Join(plugin.LocalDir, plugin.ExecutablePrefix+name), []byte(script), 0755)
+	}
+
+	modules, skipped, err := LoadModules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if FindModuleByKey(modules, "good") == nil || FindModuleByKey(modules, "orphan") != nil {
+		t.Errorf("expected only the good plugin to load, got %v", keys(modules))
+	}
+	var names []string
+	for _, err := range skipped {
+		var pluginErr *plugin.PluginError
+		if errors.As(err, &pluginErr) {
+			names = append(names, pluginErr.Plugin)
+		}
+	}
+	slices.Sort(names)
+	want := []string{plugin.ExecutablePrefix + "broken", plugin.ExecutablePrefix + "duplicate", plugin.ExecutablePrefix + "orphan"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected %v to be skipped, got %v", want, skipped)
+	}
+}
@@ -0,0 +1,92 @@
+package gitignore
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"code-template/helpers/project"
+)
+
+const fileName = ".gitignore"
+
+// Path returns the .gitignore modules add entries to, relative to the
+// project root. In a workspace it is the one at the workspace root.
+func Path() string {
+	return project.SharedPath(fileName)
+}
+
+// Has checks if entry is in .gitignore. A directory entry like ".bin/" also
+// matches the line ".bin".
+func Has(entry string) bool {
+	f, err := os.Open(project.Path(Path()))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if matches(scanner.Text(), entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends entry to .gitignore if not already present.
+func Add(entry string) error {
+	defer project.Lock(Path())()
+	if Has(entry) {
+		return nil
+	}
+
+	content, err := os.ReadFile(project.Path(Path()))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var prefix string
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		prefix = "\n"
+	}
+
+	content = append(content, prefix+entry+"\n"...)
+	return project.WriteFile(Path(), content, 0644)
+}
+
+// Remove removes entry from .gitignore, keeping every other line.
+func Remove(entry string) error {
+	defer project.Lock(Path())()
+	content, err := os.ReadFile(project.Path(Path()))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var newLines []string
+	for _, line := range lines {
+		if !matches(line, entry) {
+			newLines = append(newLines, line)
+		}
+	}
+
+	for len(newLines) > 0 && newLines[len(newLines)-1] == "" {
+		newLines = newLines[:len(newLines)-1]
+	}
+
+	newContent := strings.Join(newLines, "\n")
+	if len(newLines) > 0 {
+		newContent += "\n"
+	}
+
+	return project.WriteFile(Path(), []byte(newContent), 0644)
+}
+
+func matches(line, entry string) bool {
+	line = strings.TrimSpace(line)
+	return line == entry || line+"/" == entry
+}
@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code-template/helpers/project"
+	"code-template/helpers/taskfile"
+	"code-template/helpers/transaction"
+	"code-template/models"
+	"code-template/services"
+)
+
+// goToolsJournal is the journal key of a migration to go-tools tool mode,
+// which belongs to no module.
+const goToolsJournal = "go-tools"
+
+// ErrNothingToMigrate is returned by MigrateGoTools when the repo already
+// records its tools in go.mod and none are left in .bin/.
+var ErrNothingToMigrate = errors.New("tools are already recorded in go.mod")
+
+// GoToolsMigrationSteps returns the steps that move a repo from .bin/ to
+// go-tools tool mode: set go-tools: tool in code-template.yml, record each
+// binary installed modules have in .bin/ as a go.mod tool directive at the
+// version it was built from, run them with go tool in Taskfile.yml and
+// finally remove them from .bin/.
+func GoToolsMigrationSteps(ctx context.Context, modules []models.Module) []transaction.Step {
+	setMode := transaction.SetKey(project.SharedPath(project.ConfigFile), services.GoToolsKey, services.GoToolsTool)
+	setMode.Skip = services.Go.ToolMode
+	steps := []transaction.Step{setMode}
+
+	binaries := migratableBinaries(modules)
+	var moved []string
+	for _, name := range binaries {
+		info, err := services.Go.GetBinaryInfo(name)
+		if err != nil {
+			continue // Not a Go binary code-template can reinstall
+		}
+		pkg := services.Package{Name: name, InstallPath: info.Package}
+		if strings.HasPrefix(info.Version, "v") {
+			// Otherwise a local "(devel)" build; go get resolves the latest
+			pkg = pkg.At(info.Version)
+		}
+		moved = append(moved, name)
+		steps = append(steps, transaction.Step{
+			Name: "record " + name + " as a go.mod tool",
+			Skip: func() bool { return services.Go.HasTool(name) },
+			Do:   func() error { return services.Go.Install(ctx, pkg) },
+			Undo: func() error { return services.Go.Uninstall(ctx, name) },
+			Effects: []transaction.Effect{
+				transaction.RunsCommand("go get -tool "+pkg.InstallPath, ""),
+				transaction.EditsFile(services.GoModFile, "add tool "+name),
+				transaction.EditsFile(services.GoSumFile, "add checksums"),
+			},
+		})
+	}
+	if len(moved) == 0 {
+		return steps
+	}
+
+	var previous []byte
+	steps = append(steps, transaction.Step{
+		Name: "run tools with go tool in " + taskfile.Path,
+		Do: func() error {
+			previous, _ = os.ReadFile(project.Path(taskfile.Path))
+			return taskfile.RewriteCommands(func(cmd string) string { return goToolCommand(cmd, moved) })
+		},
+		Undo: func() error {
+			if previous == nil {
+				return nil
+			}
+			return project.WriteFile(taskfile.Path, previous, 0644)
+		},
+		Effects: []transaction.Effect{transaction.EditsFile(taskfile.Path, "run "+strings.Join(moved, ", ")+" with go tool")},
+	})
+
+	// Last, so nothing after it can fail and need the binaries back
+	remove := transaction.Step{
+		Name: "remove migrated tools from .bin/",
+		Do: func() error {
+			var errs []error
+			for _, name := range moved {
+				if err := os.Remove(project.Path(services.Go.GetBinPath(name))); err != nil && !os.IsNotExist(err) {
+					errs = append(errs, err)
+				}
+			}
+			errs = append(errs, services.Go.CleanupBinDir())
+			return errors.Join(errs...)
+		},
+	}
+	for _, name := range moved {
+		remove.Effects = append(remove.Effects, transaction.DeletesFile(services.Go.GetBinPath(name)))
+	}
+	return append(steps, remove)
+}
+
+// MigrateGoTools carries out GoToolsMigrationSteps as one transaction, so a
+// failure leaves .bin/ and go.mod as they were, and re-records the lock of
+// the modules whose binaries moved.
+func MigrateGoTools(ctx context.Context, modules []models.Module) error {
+	steps := GoToolsMigrationSteps(ctx, modules)
+	if services.Go.ToolMode() && len(steps) == 1 {
+		return ErrNothingToMigrate
+	}
+	if err := transaction.Run(ctx, goToolsJournal, "migrate", steps); err != nil {
+		return err
+	}
+	return relockGoTools(ctx, modules)
+}
+
+// FormatGoToolsMigration describes what MigrateGoTools would do, for dry
+// runs.
+func FormatGoToolsMigration(ctx context.Context, modules []models.Module) string {
+	var b strings.Builder
+	b.WriteString("migrate to " + services.GoToolsKey + ": " + services.GoToolsTool + "\n")
+	writeSteps(&b, transaction.Plan(GoToolsMigrationSteps(ctx, modules)))
+	return b.String()
+}
+
+// relockGoTools re-records the lock of installed modules with binaries,
+// which no longer lists those moved to go.mod.
+func relockGoTools(ctx context.Context, modules []models.Module) error {
+	var errs []error
+	for _, m := range modules {
+		if ti, ok := m.(models.ToolInstaller); ok && len(ti.Binaries()) > 0 && GetInstalledVersion(m) > 0 {
+			errs = append(errs, RecordLock(ctx, m))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// migratableBinaries returns the binaries of installed modules that are in
+// .bin/, sorted by name.
+func migratableBinaries(modules []models.Module) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range modules {
+		ti, ok := m.(models.ToolInstaller)
+		if !ok || GetInstalledVersion(m) == 0 {
+			continue
+		}
+		for _, name := range ti.Binaries() {
+			if _, err := os.Stat(project.Path(services.Go.GetBinPath(name))); err == nil && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goToolCommand rewrites a task command that runs one of names from the
+// bin directory, as "./.bin/name" or ".bin/name", to run "go tool name".
+func goToolCommand(cmd string, names []string) string {
+	bin := filepath.ToSlash(services.Go.GetBinDir())
+	for _, name := range names {
+		for _, path := range []string{"./" + bin + "/" + name, bin + "/" + name} {
+			if rest, ok := strings.CutPrefix(cmd, path); ok && (rest == "" || rest[0] == ' ') {
+				return "go tool " + name + rest
+			}
+		}
+	}
+	return cmd
+}
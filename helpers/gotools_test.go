@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code-template/helpers/taskfile"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
+)
+
+// binModule installs the lint binary into .bin/.
+type binModule struct {
+	fakeModule
+}
+
+func (m *binModule) Binaries() []string               { return []string{"lint"} }
+func (m *binModule) NpmPackages() []models.NpmPackage { return nil }
+
+func TestMigrateGoTools_MovesBinariesToToolDirectives(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// A real Go binary, so its build info names the package
+	os.MkdirAll("src", 0755)
+	os.WriteFile("src/go.mod", []byte("module example.com/lint\n\ngo 1.24\n"), 0644)
+	os.WriteFile("src/main.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+	build := exec.Command("go", "build", "-o", filepath.Join(dir, ".bin", "lint"), ".")
+	build.Dir = "src"
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Skipf("cannot build a test binary: %v\n%s", err, out)
+	}
+
+	// go get -tool appends a tool directive, or with @none removes it
+	tools := t.TempDir()
+	script := "#!/bin/sh\npkg=${3%@*}\nif [ \"${3#*@}\" = none ]; then grep -v \"^tool $pkg\\$\" go.mod > go.mod.new; mv go.mod.new go.mod\nelse echo \"tool $pkg\" >> go.mod; fi\n"
+	os.WriteFile(filepath.Join(tools, "go"), []byte(script), 0755)
+	t.Setenv("PATH", tools+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	os.WriteFile("go.mod", []byte("module example.com/app\n\ngo 1.24\n"), 0644)
+	yamlhelper.SetKey(codeTemplateFileName, "lint", 1)
+	taskfile.AddTask("lint", "Lint", []string{"./.bin/lint run ./...", "echo done"})
+	modules := []models.Module{&binModule{fakeModule{key: "lint", installed: true}}}
+
+	if err := MigrateGoTools(t.Context(), modules); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !services.Go.ToolMode() || !services.Go.IsInstalled("lint") {
+		t.Error("expected tool mode with lint recorded in go.mod")
+	}
+	if _, err := os.Stat(".bin/lint"); !os.IsNotExist(err) {
+		t.Error("expected lint to be removed from .bin/")
+	}
+	task, _, _ := taskfile.GetTask("lint")
+	cmds := task.(map[string]any)["cmds"].([]any)
+	if cmds[0] != "go tool lint run ./..." || cmds[1] != "echo done" {
+		t.Errorf("expected lint to run with go tool, got %v", cmds)
+	}
+	if err := MigrateGoTools(t.Context(), modules); err != ErrNothingToMigrate {
+		t.Errorf("expected ErrNothingToMigrate, got %v", err)
+	}
+
+	mod, _ := os.ReadFile("go.mod")
+	if !strings.Contains(string(mod), "tool example.com/lint\n") {
+		t.Errorf("expected a tool directive, got:\n%s", mod)
+	}
+}
@@ -0,0 +1,388 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"code-template/helpers/lockfile"
+	"code-template/helpers/merge"
+	"code-template/helpers/project"
+	"code-template/models"
+	"code-template/services"
+)
+
+// historyFile records every install, uninstall, update and apply, one JSON
+// object per line, oldest first.
+const historyFile = ".code-template/history.jsonl"
+
+// maxSnapshotSize is the largest file whose content the history keeps.
+const maxSnapshotSize = 1 << 20
+
+// Operation outcomes recorded in the history.
+const (
+	HistoryOK     = "ok"
+	HistoryFailed = "failed"
+)
+
+// Kinds of FileChange.
+const (
+	FileCreated  = "created"
+	FileModified = "modified"
+	FileDeleted  = "deleted"
+)
+
+// ErrNothingToUndo is returned by LastUndoable when no operation in the
+// history changed files that an undo could restore.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// UndoConflictError is returned when a file an operation changed was
+// changed again since in a way that can't be merged with undoing it.
+type UndoConflictError struct {
+	Path string
+}
+
+func (e *UndoConflictError) Error() string {
+	return fmt.Sprintf("%s changed since in a way undo can't merge with", e.Path)
+}
+
+// HistoryEntry is one operation of the history.
+type HistoryEntry struct {
+	ID       int            `json:"id" yaml:"id"` // Position in the history, from 1
+	Time     time.Time      `json:"time" yaml:"time"`
+	Action   string         `json:"action" yaml:"action"` // "install", "uninstall", "update", "preset", "apply" or "undo"
+	Target   string         `json:"target" yaml:"target"` // Module or preset the operation was asked for
+	Modules  []ModuleChange `json:"modules" yaml:"modules"`
+	Files    []FileChange   `json:"files" yaml:"files"`
+	Commands []string       `json:"commands" yaml:"commands"` // Commands the operation's steps run
+	Outcome  string         `json:"outcome" yaml:"outcome"`   // HistoryOK or HistoryFailed
+	Error    string         `json:"error,omitempty" yaml:"error,omitempty"`
+	Undoes   int            `json:"undoes,omitempty" yaml:"undoes,omitempty"` // ID of the entry an undo reverted
+}
+
+// ModuleChange is a module an operation worked on, with its installed
+// version before and after (0 when not installed).
+type ModuleChange struct {
+	Key    string `json:"key" yaml:"key"`
+	Action string `json:"action" yaml:"action"`
+	From   int    `json:"from" yaml:"from"`
+	To     int    `json:"to" yaml:"to"`
+}
+
+// FileChange is a file an operation created, modified or deleted, with its
+// content before and after.
+type FileChange struct {
+	Path   string `json:"path" yaml:"path"`
+	Change string `json:"change" yaml:"change"`                     // FileCreated, FileModified or FileDeleted
+	Before string `json:"before,omitempty" yaml:"before,omitempty"` // Empty when created
+	After  string `json:"after,omitempty" yaml:"after,omitempty"`   // Empty when deleted
+}
+
+func (e HistoryEntry) String() string {
+	var modules []string
+	for _, m := range e.Modules {
+		modules = append(modules, fmt.Sprintf("%s v%d→v%d", m.Key, m.From, m.To))
+	}
+	s := fmt.Sprintf("#%d %s %s %s", e.ID, e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, e.Target)
+	if len(modules) > 0 {
+		s += " (" + strings.Join(modules, ", ") + ")"
+	}
+	return s
+}
+
+// ReadHistory returns the history of the project, oldest first.
+func ReadHistory() ([]HistoryEntry, error) {
+	file, err := os.Open(project.Path(historyFile))
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", historyFile, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// appendHistory gives e the next ID and appends it to the history.
+func appendHistory(e *HistoryEntry) error {
+	defer project.Lock(historyFile)()
+	entries, err := ReadHistory()
+	if err != nil {
+		return err
+	}
+	e.ID = 1
+	if len(entries) > 0 {
+		e.ID = entries[len(entries)-1].ID + 1
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path := project.Path(historyFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// recordOperation runs an operation, planned as plans, and appends it to the
+// history with the modules it worked on, the commands its steps run and how
+// the files they touch changed. code-template.yml and code-template.lock are
+// always watched. Tools built into .bin/ are left out, like other files that
+// aren't text: commands produce them, and undo doesn't revert commands. An
+// operation with nothing planned is not recorded.
+func recordOperation(action, target string, plans []ModulePlan, run func() error) error {
+	if len(plans) == 0 {
+		return run()
+	}
+
+	entry := HistoryEntry{Time: time.Now(), Action: action, Target: target, Commands: []string{}, Outcome: HistoryOK}
+	paths := []string{project.ConfigFile, lockfile.Path}
+	seen := make(map[string]bool)
+	for _, p := range plans {
+		if !seen[p.Module.GetKey()] {
+			seen[p.Module.GetKey()] = true
+			entry.Modules = append(entry.Modules, ModuleChange{Key: p.Module.GetKey(), Action: p.Action, From: GetInstalledVersion(p.Module)})
+		}
+		for _, step := range p.Steps {
+			if step.Skipped {
+				continue
+			}
+			for _, effect := range step.Effects {
+				switch {
+				case effect.Verb == "run" && effect.Detail != "":
+					entry.Commands = append(entry.Commands, effect.Target+" (in "+effect.Detail+")")
+				case effect.Verb == "run":
+					entry.Commands = append(entry.Commands, effect.Target)
+				case !isToolBinary(effect.Target):
+					paths = append(paths, effect.Target)
+				}
+			}
+		}
+	}
+	before := snapshotFiles(paths)
+
+	err := run()
+	if err != nil {
+		entry.Outcome, entry.Error = HistoryFailed, err.Error()
+	}
+	for i := range entry.Modules {
+		if m := findModuleInPlans(plans, entry.Modules[i].Key); m != nil {
+			entry.Modules[i].To = GetInstalledVersion(m)
+		}
+	}
+	entry.Files = fileChanges(before, snapshotFiles(paths))
+
+	if herr := appendHistory(&entry); herr != nil {
+		return errors.Join(err, fmt.Errorf("record history: %w", herr))
+	}
+	return err
+}
+
+// LastUndoable returns the most recent operation that changed files and
+// was not undone yet. Undos themselves are not undone.
+func LastUndoable() (HistoryEntry, error) {
+	entries, err := ReadHistory()
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	undone := make(map[int]bool)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case e.Action == "undo":
+			if e.Outcome == HistoryOK {
+				undone[e.Undoes] = true
+			}
+		case !undone[e.ID] && len(e.Files) > 0:
+			return e, nil
+		}
+	}
+	return HistoryEntry{}, ErrNothingToUndo
+}
+
+// Undo reverts the files e changed to their content before e, keeping
+// changes made to them since where they merge cleanly, and records the undo
+// in the history. Nothing is written unless every file can be reverted.
+// Commands e ran, such as tool installs, are not reverted.
+func Undo(e HistoryEntry) error {
+	reverted := make(map[string][]byte, len(e.Files))
+	for _, fc := range e.Files {
+		content, err := undoneContent(fc)
+		if err != nil {
+			return err
+		}
+		reverted[fc.Path] = content
+	}
+
+	undo := HistoryEntry{Time: time.Now(), Action: "undo", Target: e.Action + " " + e.Target, Commands: []string{}, Outcome: HistoryOK, Undoes: e.ID}
+	for _, m := range e.Modules {
+		undo.Modules = append(undo.Modules, ModuleChange{Key: m.Key, Action: "undo " + m.Action, From: m.To, To: m.From})
+	}
+	var paths []string
+	for path := range reverted {
+		paths = append(paths, path)
+	}
+	before := snapshotFiles(paths)
+
+	var errs []error
+	for _, path := range paths {
+		if err := writeReverted(path, reverted[path]); err != nil {
+			errs = append(errs, fmt.Errorf("revert %s: %w", path, err))
+		}
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		undo.Outcome, undo.Error = HistoryFailed, err.Error()
+	}
+	undo.Files = fileChanges(before, snapshotFiles(paths))
+
+	if herr := appendHistory(&undo); herr != nil {
+		return errors.Join(err, fmt.Errorf("record history: %w", herr))
+	}
+	return err
+}
+
+// undoneContent returns what fc.Path holds once fc is undone, nil for a
+// file that shouldn't exist. A file changed since fc is merged: the change
+// from fc's after to before content is applied to the current content.
+func undoneContent(fc FileChange) ([]byte, error) {
+	current, err := os.ReadFile(project.Path(fc.Path))
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var before []byte
+	if fc.Change != FileCreated {
+		before = []byte(fc.Before)
+	}
+	switch {
+	case fc.Change == FileDeleted && !exists:
+		return before, nil
+	case fc.Change == FileCreated && !exists:
+		return nil, nil
+	case fc.Change == FileDeleted, !exists:
+		return nil, &UndoConflictError{Path: fc.Path}
+	case string(current) == fc.After:
+		return before, nil
+	}
+
+	result := merge.File(fc.Path, []byte(fc.After), current, before, "current", "undo")
+	if !result.Clean() {
+		return nil, &UndoConflictError{Path: fc.Path}
+	}
+	if fc.Change == FileCreated && len(bytes.TrimSpace(result.Content)) == 0 {
+		return nil, nil
+	}
+	return result.Content, nil
+}
+
+// writeReverted writes content to path, or removes path when content is nil.
+func writeReverted(path string, content []byte) error {
+	defer project.Lock(path)()
+	if content == nil {
+		if err := os.Remove(project.Path(path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(project.Path(path)), 0755); err != nil {
+		return err
+	}
+	return project.WriteFile(path, content, 0644)
+}
+
+// isToolBinary reports whether path is a tool built into .bin/.
+func isToolBinary(path string) bool {
+	return filepath.Dir(filepath.Clean(path)) == filepath.Clean(services.Go.GetBinDir())
+}
+
+// snapshotFiles reads the regular files among paths; missing files and
+// directories are left out. Files that aren't text or are larger than
+// maxSnapshotSize map to nil, since history lines are JSON and stay small.
+func snapshotFiles(paths []string) map[string][]byte {
+	snapshot := make(map[string][]byte)
+	for _, path := range paths {
+		info, err := os.Stat(project.Path(path))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Size() > maxSnapshotSize {
+			snapshot[path] = nil
+			continue
+		}
+		data, err := os.ReadFile(project.Path(path))
+		switch {
+		case err != nil:
+		case !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0:
+			snapshot[path] = nil
+		default:
+			snapshot[path] = append([]byte{}, data...)
+		}
+	}
+	return snapshot
+}
+
+// fileChanges compares two snapshots of the same paths, sorted by path.
+// Files that aren't text in either snapshot are left out.
+func fileChanges(before, after map[string][]byte) []FileChange {
+	changes := []FileChange{}
+	for path, old := range before {
+		data, ok := after[path]
+		switch {
+		case old == nil || ok && data == nil:
+		case !ok:
+			changes = append(changes, FileChange{Path: path, Change: FileDeleted, Before: string(old)})
+		case !bytes.Equal(old, data):
+			changes = append(changes, FileChange{Path: path, Change: FileModified, Before: string(old), After: string(data)})
+		}
+	}
+	for path, data := range after {
+		if _, ok := before[path]; !ok && data != nil {
+			changes = append(changes, FileChange{Path: path, Change: FileCreated, After: string(data)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func findModuleInPlans(plans []ModulePlan, key string) models.Module {
+	for _, p := range plans {
+		if p.Module.GetKey() == key {
+			return p.Module
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code-template/helpers/transaction"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
+)
+
+// steppedModule writes <key>.txt and records itself in code-template.yml.
+type steppedModule struct {
+	fakeModule
+}
+
+func (m *steppedModule) IsInstalled() bool {
+	_, exists, _ := yamlhelper.GetValue(codeTemplateFileName, m.key)
+	return exists
+}
+
+func (m *steppedModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, m.key, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
+}
+
+func (m *steppedModule) Steps(context.Context, string) []transaction.Step {
+	return []transaction.Step{
+		transaction.WriteFile("write "+m.key, m.key+".txt", []byte(m.key+"\n")),
+		transaction.SetKey(codeTemplateFileName, m.key, 1),
+	}
+}
+
+func TestUndo_RevertsLastOperationAndKeepsLaterChanges(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "other", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := &steppedModule{fakeModule{key: "a"}}, &steppedModule{fakeModule{key: "b"}}
+	modules := []models.Module{a, b}
+	for _, m := range modules {
+		if _, err := InstallWithDependencies(t.Context(), modules, m, nil); err != nil {
+			t.Fatalf("install %s: %v", m.GetKey(), err)
+		}
+	}
+
+	entry, err := LastUndoable()
+	if err != nil || entry.Target != "b" || entry.Modules[0] != (ModuleChange{Key: "b", Action: "install", From: 0, To: 1}) {
+		t.Fatalf("expected the install of b, got %v (%v)", entry, err)
+	}
+	if err := Undo(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("b.txt"); !os.IsNotExist(err) {
+		t.Error("expected b.txt to be removed")
+	}
+	if b.IsInstalled() || !a.IsInstalled() {
+		t.Error("expected only b to be uninstalled")
+	}
+
+	// a's file was edited since, so undoing its install would lose the edit
+	os.WriteFile("a.txt", []byte("edited\n"), 0644)
+	entry, err = LastUndoable()
+	if err != nil || entry.Target != "a" {
+		t.Fatalf("expected the install of a next, got %v (%v)", entry, err)
+	}
+	var conflict *UndoConflictError
+	if err := Undo(entry); !errors.As(err, &conflict) || conflict.Path != "a.txt" {
+		t.Fatalf("expected a conflict on a.txt, got %v", err)
+	}
+	if !a.IsInstalled() {
+		t.Error("expected a failed undo to leave everything in place")
+	}
+
+	os.WriteFile("a.txt", []byte("a\n"), 0644)
+	if err := Undo(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.IsInstalled() {
+		t.Error("expected a to be uninstalled")
+	}
+	if _, exists, _ := yamlhelper.GetValue(codeTemplateFileName, "other"); !exists {
+		t.Error("expected entries from before the history to be kept")
+	}
+	if _, err := LastUndoable(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected nothing left to undo, got %v", err)
+	}
+
+	history, err := ReadHistory()
+	if err != nil || len(history) != 4 || history[3].Action != "undo" || history[3].Undoes != 1 {
+		t.Errorf("expected two installs and two undos, got %v (%v)", history, err)
+	}
+}
+
+// toolModule builds a tool into .bin/, writes an image and records itself
+// in code-template.yml.
+type toolModule struct {
+	steppedModule
+}
+
+// binary is content that isn't text.
+var binary = []byte{0x7f, 'E', 'L', 'F', 0, 0xff, 0xfe}
+
+func (m *toolModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, m.key, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
+}
+
+func (m *toolModule) Steps(context.Context, string) []transaction.Step {
+	bin := services.Go.GetBinPath("tool")
+	return []transaction.Step{
+		{
+			Name: "install tool",
+			Do: func() error {
+				if err := os.MkdirAll(filepath.Dir(bin), 0755); err != nil {
+					return err
+				}
+				return os.WriteFile(bin, binary, 0755)
+			},
+			Effects: transaction.InstallsGoTool(services.Package{Name: "tool", InstallPath: "example.com/tool@v1.0.0"}, "tool"),
+		},
+		transaction.WriteFile("write logo", "logo.png", binary),
+		transaction.SetKey(codeTemplateFileName, m.key, 1),
+	}
+}
+
+func TestUndo_LeavesToolBinariesAndNonTextFilesAlone(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	m := &toolModule{steppedModule{fakeModule{key: "tool"}}}
+	if _, err := InstallWithDependencies(t.Context(), []models.Module{m}, m, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, err := LastUndoable()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, fc := range entry.Files {
+		if fc.Path == services.Go.GetBinPath("tool") || fc.Path == "logo.png" {
+			t.Errorf("expected %s to be left out of the history", fc.Path)
+		}
+	}
+
+	if err := Undo(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.IsInstalled() {
+		t.Error("expected the code-template.yml entry to be undone")
+	}
+	for _, path := range []string{services.Go.GetBinPath("tool"), "logo.png"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+	if entries, err := ReadHistory(); err != nil || len(entries) != 2 {
+		t.Errorf("expected the install and its undo in the history, got %d (%v)", len(entries), err)
+	}
+}
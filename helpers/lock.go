@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/models"
+	"code-template/services"
+)
+
+// InstallModule installs m and records what it installed in code-template.lock.
+func InstallModule(ctx context.Context, m models.Module) error {
+	if err := m.Install(ctx); err != nil {
+		return err
+	}
+	return RecordLock(ctx, m)
+}
+
+// UninstallModule uninstalls m and drops its code-template.lock entry.
+func UninstallModule(ctx context.Context, m models.Module) error {
+	err := m.Uninstall(ctx)
+	return errors.Join(err, lockfile.Remove(m.GetKey()))
+}
+
+// RecordLock writes the lock entry of an installed module: the hash of each
+// managed file as shipped, each binary in .bin/ with its resolved Go module
+// version, and each npm package version. Binaries the module found already
+// installed elsewhere and packages npm can't resolve are not locked.
+func RecordLock(ctx context.Context, m models.Module) error {
+	return recordLock(ctx, m, m.GetVersion())
+}
+
+// recordLock writes m's lock entry for an installation at version, which
+// adopted modules may be behind the current one at.
+func recordLock(ctx context.Context, m models.Module, version int) error {
+	entry := lockfile.Module{Version: version}
+
+	for _, f := range filesAt(m, version) {
+		entry.Files = append(entry.Files, lockfile.File{Path: f.Path, SHA256: lockfile.Hash(f.Content)})
+	}
+
+	if ti, ok := m.(models.ToolInstaller); ok {
+		for _, name := range ti.Binaries() {
+			path := services.Go.GetBinPath(name)
+			if _, err := os.Stat(project.Path(path)); os.IsNotExist(err) {
+				continue
+			}
+			info, err := services.Go.GetBinaryInfo(name)
+			if err != nil {
+				return fmt.Errorf("lock %s: %w", path, err)
+			}
+			hash, err := lockfile.HashFile(path)
+			if err != nil {
+				return fmt.Errorf("lock %s: %w", path, err)
+			}
+			entry.Binaries = append(entry.Binaries, lockfile.Binary{
+				Path:     path,
+				Package:  info.Package,
+				Module:   info.Module,
+				Version:  info.Version,
+				Platform: runtime.GOOS + "/" + runtime.GOARCH,
+				SHA256:   hash,
+			})
+		}
+		for _, pkg := range ti.NpmPackages() {
+			version, err := services.NPM.InstalledVersion(ctx, pkg.Name, pkg.Dir)
+			if err != nil {
+				continue
+			}
+			entry.Packages = append(entry.Packages, lockfile.Package{Name: pkg.Name, Version: version, Dir: pkg.Dir})
+		}
+	}
+
+	return lockfile.Set(m.GetKey(), entry)
+}
@@ -0,0 +1,193 @@
+package lockfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/goccy/go-yaml"
+
+	"code-template/helpers/project"
+)
+
+// Path is the lockfile, kept next to code-template.yml and committed with it.
+const Path = "code-template.lock"
+
+// Lock records exactly what each installed module put into the repo.
+type Lock struct {
+	Modules map[string]Module `yaml:"modules"`
+}
+
+// Module is the lock entry of one installed module.
+type Module struct {
+	Version  int       `yaml:"version"`
+	Files    []File    `yaml:"files,omitempty"`
+	Binaries []Binary  `yaml:"binaries,omitempty"`
+	Packages []Package `yaml:"packages,omitempty"`
+}
+
+// File is a managed file and the hash of the content the module shipped.
+type File struct {
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// Binary is a Go binary installed into .bin/.
+type Binary struct {
+	Path     string `yaml:"path"`     // e.g. ".bin/golangci-lint"
+	Package  string `yaml:"package"`  // Main package path, for go install
+	Module   string `yaml:"module"`   // Go module path
+	Version  string `yaml:"version"`  // Resolved module version
+	Platform string `yaml:"platform"` // GOOS/GOARCH the hash applies to
+	SHA256   string `yaml:"sha256"`
+}
+
+// Package is an npm package, installed globally when Dir is empty.
+type Package struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	Dir     string `yaml:"dir,omitempty"`
+}
+
+// Read loads the lockfile, returning an empty lock if it doesn't exist.
+func Read() (*Lock, error) {
+	lock := &Lock{Modules: make(map[string]Module)}
+	data, err := os.ReadFile(project.Path(Path))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, err
+	}
+	if lock.Modules == nil {
+		lock.Modules = make(map[string]Module)
+	}
+	return lock, nil
+}
+
+// Write saves the lock, removing the file once no module is left in it.
+func (l *Lock) Write() error {
+	if len(l.Modules) == 0 {
+		if err := os.Remove(project.Path(Path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	out, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return project.WriteFile(Path, out, 0644)
+}
+
+// Get returns the lock entry of a module.
+func Get(key string) (Module, bool) {
+	lock, err := Read()
+	if err != nil {
+		return Module{}, false
+	}
+	entry, ok := lock.Modules[key]
+	return entry, ok
+}
+
+// Set records the lock entry of a module.
+func Set(key string, entry Module) error {
+	defer project.Lock(Path)()
+	lock, err := Read()
+	if err != nil {
+		return err
+	}
+	lock.Modules[key] = entry
+	return lock.Write()
+}
+
+// Remove deletes the lock entry of a module.
+func Remove(key string) error {
+	defer project.Lock(Path)()
+	lock, err := Read()
+	if err != nil {
+		return err
+	}
+	if _, ok := lock.Modules[key]; !ok {
+		return nil
+	}
+	delete(lock.Modules, key)
+	return lock.Write()
+}
+
+// Intact reports whether every file and binary the lock entry of a module
+// records is still in the repo. A module without an entry, installed before
+// code-template.lock existed, is taken to be intact. npm packages are left
+// to the module, which knows where they are installed.
+func Intact(key string) bool {
+	entry, ok := Get(key)
+	if !ok {
+		return true
+	}
+	for _, f := range entry.Files {
+		if _, err := os.Stat(project.Path(f.Path)); err != nil {
+			return false
+		}
+	}
+	for _, b := range entry.Binaries {
+		if _, err := os.Stat(project.Path(b.Path)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// File returns the locked entry for a managed file.
+func (m Module) File(path string) (File, bool) {
+	for _, f := range m.Files {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// Binary returns the locked entry for a binary.
+func (m Module) Binary(path string) (Binary, bool) {
+	for _, b := range m.Binaries {
+		if b.Path == path {
+			return b, true
+		}
+	}
+	return Binary{}, false
+}
+
+// Package returns the locked entry for an npm package.
+func (m Module) Package(name, dir string) (Package, bool) {
+	for _, p := range m.Packages {
+		if p.Name == name && p.Dir == dir {
+			return p, true
+		}
+	}
+	return Package{}, false
+}
+
+// Hash returns the hex SHA-256 of data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashFile returns the hex SHA-256 of a file's contents.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(project.Path(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,65 @@
+package lockfile
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetGetRemove(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := Set("b", Module{Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	entry := Module{
+		Version:  2,
+		Files:    []File{{Path: ".golangci.yml", SHA256: Hash([]byte("x"))}},
+		Packages: []Package{{Name: "tdd-guard", Version: "1.0.0"}},
+	}
+	if err := Set("a", entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modules are written in key order so the file diffs cleanly
+	data, _ := os.ReadFile(Path)
+	if strings.Index(string(data), "a:") > strings.Index(string(data), "b:") {
+		t.Errorf("expected sorted modules, got:\n%s", data)
+	}
+
+	got, ok := Get("a")
+	if !ok || got.Version != 2 {
+		t.Fatalf("expected entry a at v2, got %+v", got)
+	}
+	if _, ok := got.Package("tdd-guard", ""); !ok {
+		t.Error("expected tdd-guard package entry")
+	}
+
+	Remove("a")
+	Remove("b")
+	if _, err := os.Stat(Path); !os.IsNotExist(err) {
+		t.Error("expected empty lockfile to be removed")
+	}
+}
+
+func TestIntact(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if !Intact("a") {
+		t.Error("expected a module without an entry to be intact")
+	}
+	os.WriteFile(".golangci.yml", []byte("x"), 0644)
+	Set("a", Module{
+		Version:  1,
+		Files:    []File{{Path: ".golangci.yml", SHA256: Hash([]byte("x"))}},
+		Binaries: []Binary{{Path: ".bin/golangci-lint"}},
+	})
+	if Intact("a") {
+		t.Error("expected a missing binary to break the entry")
+	}
+	os.MkdirAll(".bin", 0755)
+	os.WriteFile(".bin/golangci-lint", nil, 0755)
+	if !Intact("a") {
+		t.Error("expected the entry to be intact")
+	}
+}
@@ -0,0 +1,139 @@
+package merge
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"code-template/helpers/diff"
+)
+
+// Conflict markers, matching git so editors highlight them.
+const (
+	markerOurs   = "<<<<<<< "
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> "
+)
+
+// Result is the outcome of a three-way merge.
+type Result struct {
+	Content   []byte
+	Conflicts []int    // 1-based lines of each opening conflict marker
+	Keys      []string // Conflicting key paths (structured merges only)
+}
+
+// Clean returns true if the merge needed no conflict markers.
+func (r Result) Clean() bool {
+	return len(r.Conflicts) == 0
+}
+
+// File merges the changes from base to theirs into ours. YAML and JSON files
+// are merged by structure so edits to different keys never conflict;
+// everything else is merged line by line. Unresolvable changes are left
+// between conflict markers labelled oursLabel and theirsLabel.
+func File(path string, base, ours, theirs []byte, oursLabel, theirsLabel string) Result {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return structured(base, ours, theirs, oursLabel, theirsLabel, encodeYAML)
+	case ".json":
+		return structured(base, ours, theirs, oursLabel, theirsLabel, encodeJSON)
+	}
+	return Lines(base, ours, theirs, oursLabel, theirsLabel)
+}
+
+// Lines performs a line-based three-way merge (diff3).
+func Lines(base, ours, theirs []byte, oursLabel, theirsLabel string) Result {
+	b, o, t := diff.SplitLines(base), diff.SplitLines(ours), diff.SplitLines(theirs)
+	inOurs, inTheirs := matches(b, o), matches(b, t)
+
+	var out []string
+	var conflicts []int
+	i, x, y := 0, 0, 0
+	for i < len(b) || x < len(o) || y < len(t) {
+		// Stable line: unchanged on both sides
+		if i < len(b) && inOurs[i] == x && inTheirs[i] == y {
+			out = append(out, b[i])
+			i, x, y = i+1, x+1, y+1
+			continue
+		}
+
+		// Unstable chunk: runs up to the next base line both sides kept
+		k := i
+		for k < len(b) && (inOurs[k] < 0 || inTheirs[k] < 0) {
+			k++
+		}
+		endO, endT := len(o), len(t)
+		if k < len(b) {
+			endO, endT = inOurs[k], inTheirs[k]
+		}
+		baseChunk, oursChunk, theirsChunk := b[i:k], o[x:endO], t[y:endT]
+
+		switch {
+		case slices.Equal(oursChunk, baseChunk):
+			out = append(out, theirsChunk...)
+		case slices.Equal(theirsChunk, baseChunk), slices.Equal(oursChunk, theirsChunk):
+			out = append(out, oursChunk...)
+		default:
+			conflicts = append(conflicts, len(out)+1)
+			out = append(out, markerOurs+oursLabel)
+			out = append(out, oursChunk...)
+			out = append(out, markerSep)
+			out = append(out, theirsChunk...)
+			out = append(out, markerTheirs+theirsLabel)
+		}
+		i, x, y = k, endO, endT
+	}
+	return Result{Content: join(out), Conflicts: conflicts}
+}
+
+// wholeFile marks the entire file as one conflict.
+func wholeFile(ours, theirs []byte, oursLabel, theirsLabel string) Result {
+	out := []string{markerOurs + oursLabel}
+	out = append(out, diff.SplitLines(ours)...)
+	out = append(out, markerSep)
+	out = append(out, diff.SplitLines(theirs)...)
+	out = append(out, markerTheirs+theirsLabel)
+	return Result{Content: join(out), Conflicts: []int{1}}
+}
+
+// FindMarkers returns the 1-based lines of opening conflict markers in data.
+func FindMarkers(data []byte) []int {
+	var lines []int
+	for n, line := range diff.SplitLines(data) {
+		if strings.HasPrefix(line, markerOurs) {
+			lines = append(lines, n+1)
+		}
+	}
+	return lines
+}
+
+// matches maps each line of base to its position in other, or -1 if the
+// line was removed.
+func matches(base, other []string) []int {
+	m := make([]int, len(base))
+	for i := range m {
+		m[i] = -1
+	}
+	for _, op := range diff.Compute(base, other) {
+		if op.Kind == diff.Equal {
+			m[op.A] = op.B
+		}
+	}
+	return m
+}
+
+func join(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// keyPath joins a parent key path and a child key.
+func keyPath(parent string, key any) string {
+	if parent == "" {
+		return fmt.Sprint(key)
+	}
+	return parent + "." + fmt.Sprint(key)
+}
@@ -0,0 +1,90 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLines_Clean(t *testing.T) {
+	base := []byte("a\nb\nc\nd\ne\n")
+	ours := []byte("a\nB\nc\nd\ne\n")
+	theirs := []byte("a\nb\nc\nd\nE\n")
+
+	r := Lines(base, ours, theirs, "local", "v2")
+	if !r.Clean() {
+		t.Fatalf("expected clean merge, got conflicts at %v:\n%s", r.Conflicts, r.Content)
+	}
+	if got := string(r.Content); got != "a\nB\nc\nd\nE\n" {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestLines_Conflict(t *testing.T) {
+	base := []byte("a\nb\nc\n")
+	ours := []byte("a\nours\nc\n")
+	theirs := []byte("a\ntheirs\nc\n")
+
+	r := Lines(base, ours, theirs, "local", "v2")
+	want := "a\n<<<<<<< local\nours\n=======\ntheirs\n>>>>>>> v2\nc\n"
+	if got := string(r.Content); got != want {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+	if len(r.Conflicts) != 1 || r.Conflicts[0] != 2 {
+		t.Errorf("expected a conflict at line 2, got %v", r.Conflicts)
+	}
+	if got := FindMarkers(r.Content); len(got) != 1 || got[0] != 2 {
+		t.Errorf("expected FindMarkers to report line 2, got %v", got)
+	}
+}
+
+func TestFile_YAMLAdjacentKeys(t *testing.T) {
+	// Adjacent edits conflict line by line but touch different keys
+	base := []byte("linters:\n  enable:\n    - errcheck\nrun:\n  timeout: 1m\n")
+	ours := []byte("linters:\n  enable:\n    - errcheck\nrun:\n  timeout: 5m\n")
+	theirs := []byte("linters:\n  enable:\n    - errcheck\nrun:\n  timeout: 1m\n  tests: true\n")
+
+	r := File(".golangci.yml", base, ours, theirs, "local", "v2")
+	if !r.Clean() {
+		t.Fatalf("expected clean merge, got:\n%s", r.Content)
+	}
+	got := string(r.Content)
+	if !strings.Contains(got, "timeout: 5m") || !strings.Contains(got, "tests: true") {
+		t.Errorf("expected both changes, got:\n%s", got)
+	}
+}
+
+func TestFile_YAMLKeepsCommentsWhenLinesMerge(t *testing.T) {
+	base := []byte("# shipped\na: 1\nb: 2\nc: 3\nd: 4\n")
+	ours := []byte("# shipped\n# mine\na: 1\nb: 2\nc: 3\nd: 4\n")
+	theirs := []byte("# shipped\na: 1\nb: 2\nc: 3\nd: 5\n")
+
+	r := File("x.yaml", base, ours, theirs, "local", "v2")
+	if got := string(r.Content); got != "# shipped\n# mine\na: 1\nb: 2\nc: 3\nd: 5\n" {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestFile_YAMLSameKeyConflict(t *testing.T) {
+	base := []byte("a: 1\n")
+	ours := []byte("a: 2\n")
+	theirs := []byte("a: 3\n")
+
+	r := File("x.yml", base, ours, theirs, "local", "v2")
+	if r.Clean() || len(r.Keys) != 1 || r.Keys[0] != "a" {
+		t.Fatalf("expected a conflict on key a, got %+v", r)
+	}
+}
+
+func TestFile_JSON(t *testing.T) {
+	base := []byte("{\n  \"a\": 1,\n  \"b\": 2\n}\n")
+	ours := []byte("{\n  \"a\": 10,\n  \"b\": 2\n}\n")
+	theirs := []byte("{\n  \"a\": 1,\n  \"b\": 20\n}\n")
+
+	r := File("tsconfig.json", base, ours, theirs, "local", "v2")
+	if !r.Clean() {
+		t.Fatalf("expected clean merge, got:\n%s", r.Content)
+	}
+	if got := string(r.Content); got != "{\n  \"a\": 10,\n  \"b\": 20\n}\n" {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
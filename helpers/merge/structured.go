@@ -0,0 +1,215 @@
+package merge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// structured merges YAML or JSON documents key by key. A clean line merge
+// is preferred whenever it means the same thing, since it keeps comments
+// and formatting; otherwise the merged structure is re-encoded.
+func structured(base, ours, theirs []byte, oursLabel, theirsLabel string, encode func(any) ([]byte, error)) Result {
+	lines := Lines(base, ours, theirs, oursLabel, theirsLabel)
+
+	b, errB := decode(base)
+	o, errO := decode(ours)
+	t, errT := decode(theirs)
+	if errB != nil || errO != nil || errT != nil {
+		// Not parseable (e.g. JSON with comments): lines are all we have
+		return lines
+	}
+
+	var keys []string
+	merged := mergeValue("", b, o, t, &keys)
+	if len(keys) > 0 {
+		if lines.Clean() {
+			// The line merge missed a structural conflict (e.g. the same
+			// key added in two places), so leave the whole file to the user
+			r := wholeFile(ours, theirs, oursLabel, theirsLabel)
+			r.Keys = keys
+			return r
+		}
+		lines.Keys = keys
+		return lines
+	}
+
+	if lines.Clean() {
+		if v, err := decode(lines.Content); err == nil && equal(v, merged) {
+			return lines
+		}
+	}
+	content, err := encode(merged)
+	if err != nil {
+		return lines
+	}
+	return Result{Content: content}
+}
+
+func decode(data []byte) (any, error) {
+	var v any
+	if err := yaml.UnmarshalWithOptions(data, &v, yaml.UseOrderedMap()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// mergeValue merges one node. Maps merge per key; any other value is taken
+// from whichever side changed it, and conflicts when both sides did.
+func mergeValue(path string, base, ours, theirs any, conflicts *[]string) any {
+	switch {
+	case equal(ours, theirs), equal(theirs, base):
+		return ours
+	case equal(ours, base):
+		return theirs
+	}
+
+	b, bok := base.(yaml.MapSlice)
+	o, ook := ours.(yaml.MapSlice)
+	t, tok := theirs.(yaml.MapSlice)
+	if !ook || !tok {
+		*conflicts = append(*conflicts, path)
+		return ours
+	}
+	if !bok {
+		b = nil
+	}
+
+	// Keep our key order and append keys only theirs added
+	var out yaml.MapSlice
+	for _, item := range o {
+		bv, inBase := lookup(b, item.Key)
+		tv, inTheirs := lookup(t, item.Key)
+		switch {
+		case inTheirs:
+			out = append(out, yaml.MapItem{Key: item.Key, Value: mergeValue(keyPath(path, item.Key), bv, item.Value, tv, conflicts)})
+		case !inBase:
+			// Added by us
+			out = append(out, item)
+		case equal(item.Value, bv):
+			// Removed by them and untouched by us
+		default:
+			*conflicts = append(*conflicts, keyPath(path, item.Key))
+			out = append(out, item)
+		}
+	}
+	for _, item := range t {
+		if _, inOurs := lookup(o, item.Key); inOurs {
+			continue
+		}
+		bv, inBase := lookup(b, item.Key)
+		switch {
+		case !inBase:
+			// Added by them
+			out = append(out, item)
+		case equal(item.Value, bv):
+			// Removed by us and untouched by them
+		default:
+			*conflicts = append(*conflicts, keyPath(path, item.Key))
+		}
+	}
+	return out
+}
+
+func lookup(m yaml.MapSlice, key any) (any, bool) {
+	for _, item := range m {
+		if fmt.Sprint(item.Key) == fmt.Sprint(key) {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// equal compares decoded values, ignoring the order of map keys.
+func equal(a, b any) bool {
+	am, aok := a.(yaml.MapSlice)
+	bm, bok := b.(yaml.MapSlice)
+	if aok || bok {
+		if !aok || !bok || len(am) != len(bm) {
+			return false
+		}
+		for _, item := range am {
+			v, ok := lookup(bm, item.Key)
+			if !ok || !equal(item.Value, v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	as, aok := a.([]any)
+	bs, bok := b.([]any)
+	if aok || bok {
+		if !aok || !bok || len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if !equal(as[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return fmt.Sprintf("%T %v", a, a) == fmt.Sprintf("%T %v", b, b)
+}
+
+func encodeYAML(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// encodeJSON writes v as indented JSON, keeping map key order.
+func encodeJSON(v any) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := writeJSON(&compact, v); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		buf.WriteString("{")
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			key, err := json.Marshal(fmt.Sprint(item.Key))
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteString(":")
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case []any:
+		buf.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package helpers
 
 import (
+	"context"
+	"fmt"
+
+	"code-template/helpers/transaction"
 	"code-template/models"
 
 	yamlhelper "code-template/helpers/yaml"
@@ -15,8 +19,22 @@ const (
 	StateNotInstalled ModuleState = iota
 	StateOutdated
 	StateUpToDate
+	StateModified // Up to date, but managed files were edited locally
 )
 
+// String returns the state as it appears in machine-readable output.
+func (s ModuleState) String() string {
+	switch s {
+	case StateOutdated:
+		return "outdated"
+	case StateUpToDate:
+		return "up-to-date"
+	case StateModified:
+		return "modified"
+	}
+	return "not-installed"
+}
+
 // GetInstalledVersion returns the installed version of a module.
 // Returns 0 if not installed or on error.
 func GetInstalledVersion(m models.Module) int {
@@ -39,22 +57,64 @@ func GetInstalledVersion(m models.Module) int {
 	}
 }
 
-// IsOutdated returns true if the module is installed but at an older version.
+// IsOutdated returns true if the module is installed but at an older
+// version, or with tools at other versions than it pins.
 func IsOutdated(m models.Module) bool {
 	if !m.IsInstalled() {
 		return false
 	}
 	installedVersion := GetInstalledVersion(m)
-	return installedVersion < m.GetVersion()
+	return installedVersion < m.GetVersion() || len(OutdatedTools(m)) > 0
 }
 
-// UpdateModule performs an update by uninstalling then reinstalling.
-// Returns true if both operations succeed.
-func UpdateModule(m models.Module) bool {
-	if !m.Uninstall() {
-		return false
+// OutdatedTools describes the tools of m whose installed version differs
+// from the pinned one.
+func OutdatedTools(m models.Module) []string {
+	if pinner, ok := m.(transaction.ToolPinner); ok {
+		return pinner.OutdatedTools()
+	}
+	return nil
+}
+
+// DescribeOutdated tells how an outdated module differs from the current
+// one, e.g. "v1 → v2" or "v1, golangci-lint 2.1.0, want v2.8.0".
+func DescribeOutdated(m models.Module) string {
+	installed := GetInstalledVersion(m)
+	s := fmt.Sprintf("v%d → v%d", installed, m.GetVersion())
+	if installed == m.GetVersion() {
+		s = fmt.Sprintf("v%d", installed)
+	}
+	for _, tool := range OutdatedTools(m) {
+		s += ", " + tool
 	}
-	return m.Install()
+	return s
+}
+
+// UpdateModule upgrades an installed module in place by chaining its
+// per-version upgrade steps. The chain runs as a single transaction, so if
+// any step fails the previously installed version is left intact.
+// On success the module's code-template.lock entry is re-recorded. The
+// update is recorded in the history.
+func UpdateModule(ctx context.Context, m models.Module) error {
+	plans, err := PlanUpdate(ctx, m)
+	if err != nil {
+		return err
+	}
+	return recordOperation("update", m.GetKey(), plans, func() error { return updateModule(ctx, m) })
+}
+
+// updateModule is UpdateModule without the history, for operations that
+// record themselves.
+func updateModule(ctx context.Context, m models.Module) error {
+	from, to := GetInstalledVersion(m), m.GetVersion()
+	steps, err := UpgradeSteps(ctx, m, from, to)
+	if err != nil {
+		return err
+	}
+	if err := transaction.RunUpgrade(ctx, m.GetKey(), from, to, steps); err != nil {
+		return err
+	}
+	return RecordLock(ctx, m)
 }
 
 // GetModuleState returns the current state of a module.
@@ -65,5 +125,8 @@ func GetModuleState(m models.Module) ModuleState {
 	if IsOutdated(m) {
 		return StateOutdated
 	}
+	if HasDrift(m) {
+		return StateModified
+	}
 	return StateUpToDate
 }
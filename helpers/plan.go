@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// ModulePlan describes what one module operation would do, without doing it.
+type ModulePlan struct {
+	Module models.Module
+	Action string // "install", "uninstall", or "update"
+	Steps  []transaction.PlannedStep
+}
+
+func (p ModulePlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s v%d\n", p.Action, p.Module.GetName(), p.Module.GetVersion())
+	writeSteps(&b, p.Steps)
+	return b.String()
+}
+
+// writeSteps lists planned steps with their effects, skipped ones marked.
+func writeSteps(b *strings.Builder, steps []transaction.PlannedStep) {
+	for _, step := range steps {
+		if step.Skipped {
+			fmt.Fprintf(b, "  %2d. %s (skipped)\n", step.Number, step.Name)
+			continue
+		}
+		fmt.Fprintf(b, "  %2d. %s\n", step.Number, step.Name)
+		for _, effect := range step.Effects {
+			fmt.Fprintf(b, "        %s\n", effect)
+		}
+	}
+}
+
+// FormatPlans renders plans in execution order.
+func FormatPlans(plans []ModulePlan) string {
+	if len(plans) == 0 {
+		return "Nothing to do.\n"
+	}
+	parts := make([]string, 0, len(plans))
+	for _, p := range plans {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// PlanInstall returns the plan for installing target, including any missing
+// dependencies. An outdated target is planned as an update.
+func PlanInstall(ctx context.Context, modules []models.Module, target models.Module) ([]ModulePlan, error) {
+	order, err := ResolveInstallOrder(modules, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []ModulePlan
+	for _, m := range order {
+		switch GetModuleState(m) {
+		case StateNotInstalled:
+			p, err := planAction(ctx, m, transaction.ActionInstall)
+			if err != nil {
+				return nil, err
+			}
+			plans = append(plans, p)
+		case StateOutdated:
+			if m != target {
+				continue
+			}
+			update, err := PlanUpdate(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+			plans = append(plans, update...)
+		case StateUpToDate, StateModified:
+		}
+	}
+	return plans, nil
+}
+
+// PlanUninstall returns the plan for uninstalling target (and, with cascade,
+// its installed dependents).
+func PlanUninstall(ctx context.Context, modules []models.Module, target models.Module, cascade bool) ([]ModulePlan, error) {
+	order, err := ResolveUninstallOrder(modules, target, cascade)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]ModulePlan, 0, len(order))
+	for _, m := range order {
+		p, err := planAction(ctx, m, transaction.ActionUninstall)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+// PlanUpdate returns the plan for upgrading m in place from its installed
+// version to the current one.
+func PlanUpdate(ctx context.Context, m models.Module) ([]ModulePlan, error) {
+	steps, err := UpgradeSteps(ctx, m, GetInstalledVersion(m), m.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	return []ModulePlan{{
+		Module: m,
+		Action: "update",
+		Steps:  transaction.Plan(steps),
+	}}, nil
+}
+
+func planAction(ctx context.Context, m models.Module, action string) (ModulePlan, error) {
+	provider, ok := m.(transaction.Provider)
+	if !ok {
+		return ModulePlan{}, fmt.Errorf("module '%s' cannot describe its steps", m.GetName())
+	}
+	return ModulePlan{
+		Module: m,
+		Action: action,
+		Steps:  transaction.Plan(provider.Steps(ctx, action)),
+	}, nil
+}
@@ -0,0 +1,352 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
+	"code-template/models"
+
+	yamlhelper "code-template/helpers/yaml"
+)
+
+// presetsKey is the code-template.yml key holding project presets.
+const presetsKey = "presets"
+
+// UnknownModuleError is returned when a preset names a module that isn't
+// registered.
+type UnknownModuleError struct {
+	Preset string
+	Module string
+}
+
+func (e *UnknownModuleError) Error() string {
+	return fmt.Sprintf("preset %s includes unknown module '%s'", e.Preset, e.Module)
+}
+
+// InvalidPresetError is returned when a preset in code-template.yml is malformed.
+type InvalidPresetError struct {
+	Preset string
+	Reason string
+}
+
+func (e *InvalidPresetError) Error() string {
+	return fmt.Sprintf("preset %s in %s: %s", e.Preset, codeTemplateFileName, e.Reason)
+}
+
+// LoadPresets returns the built-in presets merged with those defined under
+// "presets" in code-template.yml, the workspace root's in a workspace,
+// sorted by name. A project preset replaces
+// a built-in one of the same name. Each preset is either a list of module
+// names or a map with "description" and "modules".
+func LoadPresets() ([]models.Preset, error) {
+	byName := make(map[string]models.Preset)
+	for _, p := range GetPresets() {
+		byName[p.Name] = p
+	}
+
+	value, exists, err := yamlhelper.GetValue(filepath.Join(project.WorkspaceRoot(), codeTemplateFileName), presetsKey)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		defs, ok := value.(map[string]any)
+		if !ok {
+			return nil, &InvalidPresetError{Preset: presetsKey, Reason: "expected a map of preset names"}
+		}
+		for name, def := range defs {
+			p, err := parsePreset(name, def)
+			if err != nil {
+				return nil, err
+			}
+			byName[name] = p
+		}
+	}
+
+	presets := make([]models.Preset, 0, len(byName))
+	for _, p := range byName {
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+func parsePreset(name string, def any) (models.Preset, error) {
+	p := models.Preset{Name: name}
+
+	list := def
+	if m, ok := def.(map[string]any); ok {
+		if desc, ok := m["description"].(string); ok {
+			p.Description = desc
+		}
+		list = m["modules"]
+	}
+
+	items, ok := list.([]any)
+	if !ok || len(items) == 0 {
+		return p, &InvalidPresetError{Preset: name, Reason: "expected a non-empty list of modules"}
+	}
+	for _, item := range items {
+		ref, ok := item.(string)
+		if !ok {
+			return p, &InvalidPresetError{Preset: name, Reason: fmt.Sprintf("module %v is not a name", item)}
+		}
+		p.Modules = append(p.Modules, ref)
+	}
+	return p, nil
+}
+
+// ValidatePresets checks that every preset names registered modules.
+func ValidatePresets(modules []models.Module, presets []models.Preset) error {
+	for _, p := range presets {
+		if _, err := PresetMembers(modules, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindPreset returns the preset with the given name, or nil.
+func FindPreset(presets []models.Preset, name string) *models.Preset {
+	for i := range presets {
+		if strings.EqualFold(presets[i].Name, name) {
+			return &presets[i]
+		}
+	}
+	return nil
+}
+
+// PresetMembers resolves the modules a preset names, by name or key.
+func PresetMembers(modules []models.Module, p models.Preset) ([]models.Module, error) {
+	members := make([]models.Module, 0, len(p.Modules))
+	for _, ref := range p.Modules {
+		m := FindModuleByKey(modules, ref)
+		if m == nil {
+			m = findModuleByName(modules, ref)
+		}
+		if m == nil {
+			return nil, &UnknownModuleError{Preset: p.Name, Module: ref}
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// ResolvePresetOrder returns the members of a preset and their transitive
+// requirements in install order, each module once. Conflicts within the set
+// or with installed modules are reported before anything is installed.
+func ResolvePresetOrder(modules []models.Module, p models.Preset) ([]models.Module, error) {
+	members, err := PresetMembers(modules, p)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var order []models.Module
+	for _, member := range members {
+		deps, err := requirementOrder(modules, member)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range deps {
+			if !seen[m.GetKey()] {
+				seen[m.GetKey()] = true
+				order = append(order, m)
+			}
+		}
+	}
+	if err := checkConflicts(modules, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// IsPresetInstalled returns true if every member of the preset is installed.
+func IsPresetInstalled(modules []models.Module, p models.Preset) bool {
+	members, err := PresetMembers(modules, p)
+	if err != nil {
+		return false
+	}
+	for _, m := range members {
+		if !m.IsInstalled() {
+			return false
+		}
+	}
+	return true
+}
+
+// PlanPreset returns the combined plan for installing a preset.
+func PlanPreset(ctx context.Context, modules []models.Module, p models.Preset) ([]ModulePlan, error) {
+	order, err := ResolvePresetOrder(modules, p)
+	if err != nil {
+		return nil, err
+	}
+	members, _ := PresetMembers(modules, p)
+
+	var plans []ModulePlan
+	for _, m := range order {
+		switch GetModuleState(m) {
+		case StateNotInstalled:
+			plan, err := planAction(ctx, m, transaction.ActionInstall)
+			if err != nil {
+				return nil, err
+			}
+			plans = append(plans, plan)
+		case StateOutdated:
+			if !containsModule(members, m) {
+				continue
+			}
+			update, err := PlanUpdate(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+			plans = append(plans, update...)
+		case StateUpToDate, StateModified:
+		}
+	}
+	return plans, nil
+}
+
+// Outcomes of a module within a batch operation such as a preset install.
+const (
+	OutcomeInstalled    = "installed"
+	OutcomeUpdated      = "updated"
+	OutcomeRepaired     = "repaired"
+	OutcomeUnchanged    = "already installed"
+	OutcomeFailed       = "failed"
+	OutcomeRolledBack   = "rolled back"
+	OutcomeNotAttempted = "not attempted"
+)
+
+// PresetResult is what happened to one module of a preset install or
+// another batch operation.
+type PresetResult struct {
+	Module  models.Module
+	Outcome string
+	Err     error
+}
+
+// PresetReport is the combined report of a preset install.
+type PresetReport struct {
+	Preset  string
+	Results []PresetResult
+}
+
+func (r *PresetReport) String() string {
+	var sb strings.Builder
+	for _, res := range r.Results {
+		mark := "✓"
+		switch res.Outcome {
+		case OutcomeFailed:
+			mark = "✗"
+		case OutcomeRolledBack:
+			mark = "↺"
+		case OutcomeNotAttempted:
+			mark = "-"
+		}
+		fmt.Fprintf(&sb, "  %s %-22s %s", mark, res.Module.GetName(), res.Outcome)
+		if res.Err != nil {
+			fmt.Fprintf(&sb, ": %v", res.Err)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// InstallPreset installs a preset as one operation: missing members and
+// their requirements are installed, outdated members are updated, with
+// independent modules running in parallel. If any module fails, the
+// modules this operation installed are uninstalled again in reverse order;
+// updates that already succeeded are kept. The report covers every module
+// either way. The operation is recorded in the history.
+func InstallPreset(ctx context.Context, modules []models.Module, p models.Preset, progress ProgressFunc) (*PresetReport, error) {
+	order, err := ResolvePresetOrder(modules, p)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := PlanPreset(ctx, modules, p)
+	if err != nil {
+		return nil, err
+	}
+	var report *PresetReport
+	err = recordOperation("preset", p.Name, plans, func() error {
+		var err error
+		report, err = installPreset(ctx, modules, p, order, progress)
+		return err
+	})
+	return report, err
+}
+
+func installPreset(ctx context.Context, modules []models.Module, p models.Preset, order []models.Module, progress ProgressFunc) (*PresetReport, error) {
+	members, _ := PresetMembers(modules, p)
+
+	report := &PresetReport{Preset: p.Name}
+	report.Results = runBatch(modules, order, progress, func(m models.Module) (string, error) {
+		switch GetModuleState(m) {
+		case StateNotInstalled:
+			return OutcomeInstalled, InstallModule(ctx, m)
+		case StateOutdated:
+			if containsModule(members, m) {
+				return OutcomeUpdated, updateModule(ctx, m)
+			}
+		case StateUpToDate, StateModified:
+		}
+		return OutcomeUnchanged, nil
+	})
+
+	var installed []int // Indexes into report.Results, in dependency order
+	var failure error
+	for i, res := range report.Results {
+		switch {
+		case res.Outcome == OutcomeInstalled:
+			installed = append(installed, i)
+		case res.Err != nil && failure == nil:
+			action := "install"
+			if GetInstalledVersion(res.Module) > 0 {
+				action = "update"
+			}
+			failure = &InstallError{Module: res.Module.GetName(), Action: action, Err: res.Err}
+		}
+	}
+	if failure != nil {
+		rollbackPreset(ctx, report, installed)
+	}
+	return report, failure
+}
+
+// rollbackPreset uninstalls the modules a failed preset install installed,
+// newest first, recording the outcome on each result.
+func rollbackPreset(ctx context.Context, report *PresetReport, installed []int) {
+	for i := len(installed) - 1; i >= 0; i-- {
+		res := &report.Results[installed[i]]
+		if err := UninstallModule(ctx, res.Module); err != nil {
+			res.Err = fmt.Errorf("rollback failed: %w", err)
+			continue
+		}
+		res.Outcome = OutcomeRolledBack
+	}
+}
+
+func findModuleByName(modules []models.Module, name string) models.Module {
+	for _, m := range modules {
+		if strings.EqualFold(m.GetName(), name) {
+			return m
+		}
+	}
+	return nil
+}
+
+func containsModule(list []models.Module, target models.Module) bool {
+	for _, m := range list {
+		if m == target {
+			return true
+		}
+	}
+	return false
+}
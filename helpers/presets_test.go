@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// plannedModule is a fakeModule with no steps to describe, so operations on
+// it can be planned.
+type plannedModule struct {
+	fakeModule
+}
+
+func (m *plannedModule) Steps(context.Context, string) []transaction.Step { return nil }
+
+type failingModule struct {
+	plannedModule
+}
+
+func (m *failingModule) Install(context.Context) error { return errors.New("boom") }
+
+func TestLoadPresets_ProjectOverridesBuiltIn(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile(codeTemplateFileName, []byte(`presets:
+  go: [golangci]
+  backend:
+    description: API service
+    modules: [golangci, go-test]
+`), 0644)
+
+	presets, err := LoadPresets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	goPreset := FindPreset(presets, "go")
+	if goPreset == nil || len(goPreset.Modules) != 1 {
+		t.Errorf("expected project preset to replace built-in go, got %+v", goPreset)
+	}
+	backend := FindPreset(presets, "backend")
+	if backend == nil || backend.Description != "API service" || len(backend.Modules) != 2 {
+		t.Errorf("expected backend preset, got %+v", backend)
+	}
+	if FindPreset(presets, "wails") == nil {
+		t.Error("expected built-in wails preset to remain")
+	}
+}
+
+func TestInstallPreset_RollsBackOnFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+	defer func(jobs int) { Jobs = jobs }(Jobs)
+	Jobs = 1 // One at a time, so broken starts after app
+	base := &plannedModule{fakeModule{key: "base"}}
+	app := &plannedModule{fakeModule{key: "app", requires: []string{"base"}}}
+	broken := &failingModule{plannedModule{fakeModule{key: "broken"}}}
+	modules := []models.Module{base, app, broken}
+	preset := models.Preset{Name: "stack", Modules: []string{"app", "broken"}}
+
+	report, err := InstallPreset(t.Context(), modules, preset, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if base.installed || app.installed {
+		t.Error("expected installed modules to be rolled back")
+	}
+
+	want := []string{OutcomeRolledBack, OutcomeRolledBack, OutcomeFailed}
+	if len(report.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), report.Results)
+	}
+	for i, res := range report.Results {
+		if res.Outcome != want[i] {
+			t.Errorf("result %d (%s): expected %s, got %s", i, res.Module.GetKey(), want[i], res.Outcome)
+		}
+	}
+}
+
+func TestValidatePresets_UnknownModule(t *testing.T) {
+	modules := []models.Module{&fakeModule{key: "a"}}
+	presets := []models.Preset{{Name: "p", Modules: []string{"a", "missing"}}}
+
+	var unknown *UnknownModuleError
+	if err := ValidatePresets(modules, presets); !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownModuleError, got %v", err)
+	}
+}
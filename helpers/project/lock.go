@@ -0,0 +1,63 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// locks holds a mutex per resolved path of a shared file.
+var (
+	locksMu sync.Mutex
+	locks   = make(map[string]*sync.Mutex)
+)
+
+// Lock serializes read-modify-write cycles on a file that several modules
+// edit, such as code-template.yml or Taskfile.yml, while modules install in
+// parallel. It returns the function that releases the lock:
+//
+//	defer project.Lock(path)()
+//
+// Locks are not reentrant, so only the function doing the whole cycle
+// takes one.
+func Lock(path string) (unlock func()) {
+	abs, err := filepath.Abs(Path(path))
+	if err != nil {
+		abs = Path(path)
+	}
+
+	locksMu.Lock()
+	mu, ok := locks[abs]
+	if !ok {
+		mu = &sync.Mutex{}
+		locks[abs] = mu
+	}
+	locksMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// WriteFile writes data to path by renaming a temporary file over it, so a
+// concurrent reader sees either the old or the new content, never a
+// partial write.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	path = Path(path)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,143 @@
+// Package project holds the project root that every repo path is resolved
+// against, so nothing depends on the directory the tool was started from.
+//
+// Paths elsewhere stay relative to the root (that is how they are shown in
+// plans and recorded in code-template.lock) and go through Path right
+// before touching the filesystem.
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ConfigFile marks a directory code-template already manages.
+const ConfigFile = "code-template.yml"
+
+// rootMarkers mark a directory as a project root when no code-template.yml
+// is found.
+var rootMarkers = []string{"go.mod", ".git"}
+
+// root and workspaceRoot are "." until SetRoot is called, which only tests
+// rely on. Outside a workspace they are the same directory. mu guards them,
+// as the TUI runs operations off its UI goroutine.
+var (
+	mu            sync.RWMutex
+	root          = "."
+	workspaceRoot = "."
+)
+
+// roots returns the project root and the workspace root.
+func roots() (string, string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return root, workspaceRoot
+}
+
+// Root returns the project root: the current workspace member, or the
+// project itself outside a workspace.
+func Root() string {
+	root, _ := roots()
+	return root
+}
+
+// WorkspaceRoot returns the root of the workspace, which holds the
+// resources its members share. Outside a workspace it is Root.
+func WorkspaceRoot() string {
+	_, workspaceRoot := roots()
+	return workspaceRoot
+}
+
+// Member returns the current workspace member relative to the workspace
+// root, or "" when the project root is the workspace root.
+func Member() string {
+	root, workspaceRoot := roots()
+	rel, err := filepath.Rel(workspaceRoot, root)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// SetRoot sets the project root, which is also the workspace root until a
+// member is chosen with UseMember.
+func SetRoot(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "set root", Path: abs, Err: os.ErrInvalid}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	root, workspaceRoot = abs, abs
+	return nil
+}
+
+// UseMember makes a workspace member, given relative to the workspace
+// root, the project root. An empty member selects the workspace root.
+func UseMember(member string) {
+	mu.Lock()
+	defer mu.Unlock()
+	root = filepath.Join(workspaceRoot, filepath.FromSlash(member))
+}
+
+// Path resolves a path relative to the project root. Absolute paths are
+// returned unchanged.
+func Path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(Root(), path)
+}
+
+// SharedPath returns the path of a resource the workspace shares, such as
+// .bin or .gitignore, relative to the project root: "../../.bin" in member
+// apps/api and ".bin" outside a workspace.
+func SharedPath(path string) string {
+	root, workspaceRoot := roots()
+	rel, err := filepath.Rel(root, filepath.Join(workspaceRoot, path))
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// Find returns the project root for a directory: the nearest directory at
+// or above it holding code-template.yml, else the nearest holding go.mod or
+// .git, else the directory itself.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if found, ok := walkUp(dir, []string{ConfigFile}); ok {
+		return found, nil
+	}
+	if found, ok := walkUp(dir, rootMarkers); ok {
+		return found, nil
+	}
+	return dir, nil
+}
+
+// walkUp returns the nearest directory at or above dir holding one of names.
+func walkUp(dir string, names []string) (string, bool) {
+	for {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,86 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind_PrefersCodeTemplateConfig(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "service", "cmd")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{ConfigFile, filepath.Join("service", "go.mod")} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Find(sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != dir {
+		t.Errorf("got %s, want %s", got, dir)
+	}
+}
+
+func TestFind_FallsBackToGoModOrGit(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "internal")
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != dir {
+		t.Errorf("got %s, want %s", got, dir)
+	}
+}
+
+func TestPath_ResolvesAgainstRoot(t *testing.T) {
+	dir := t.TempDir()
+	defer func(r, w string) { root, workspaceRoot = r, w }(root, workspaceRoot)
+	if err := SetRoot(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Path("Taskfile.yml"); got != filepath.Join(dir, "Taskfile.yml") {
+		t.Errorf("got %s", got)
+	}
+	if got := Path("/etc/hosts"); got != "/etc/hosts" {
+		t.Errorf("absolute path changed to %s", got)
+	}
+}
+
+func TestSharedPath_ResolvesFromMemberToWorkspaceRoot(t *testing.T) {
+	dir := t.TempDir()
+	defer func(r, w string) { root, workspaceRoot = r, w }(root, workspaceRoot)
+	if err := SetRoot(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SharedPath(".bin"); got != ".bin" {
+		t.Errorf("outside a member got %s, want .bin", got)
+	}
+
+	UseMember("apps/api")
+	if got := Member(); got != "apps/api" {
+		t.Errorf("Member() = %s, want apps/api", got)
+	}
+	want := filepath.Join("..", "..", ".bin")
+	if got := SharedPath(".bin"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := Path(SharedPath(".gitignore")); got != filepath.Join(dir, ".gitignore") {
+		t.Errorf("shared path resolves to %s", got)
+	}
+}
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"code-template/helpers/lockfile"
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// RecoverTransactions finishes or rolls back transactions that were
+// interrupted by a crash. With resume set the remaining steps are run,
+// otherwise the completed steps are undone. Returns one line per journal
+// describing what was done.
+func RecoverTransactions(ctx context.Context, modules []models.Module, resume bool) ([]string, error) {
+	journals, err := transaction.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var report []string
+	for _, j := range journals {
+		steps, err := journalSteps(ctx, modules, j)
+		if err != nil {
+			return report, err
+		}
+
+		if resume {
+			if err := j.Resume(steps); err != nil {
+				return report, fmt.Errorf("resume %s of %s: %w", j.Action, j.Module, err)
+			}
+			if err := relock(ctx, modules, j); err != nil {
+				return report, err
+			}
+			report = append(report, fmt.Sprintf("Resumed interrupted %s of %s", j.Action, j.Module))
+			continue
+		}
+
+		if err := j.Rollback(steps); err != nil {
+			return report, fmt.Errorf("roll back %s of %s: %w", j.Action, j.Module, err)
+		}
+		report = append(report, fmt.Sprintf("Rolled back interrupted %s of %s", j.Action, j.Module))
+	}
+	return report, nil
+}
+
+// PlanRecovery describes what RecoverTransactions would do, for a dry run.
+func PlanRecovery(resume bool) ([]string, error) {
+	journals, err := transaction.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	verb := "roll back"
+	if resume {
+		verb = "resume"
+	}
+	report := make([]string, 0, len(journals))
+	for _, j := range journals {
+		report = append(report, fmt.Sprintf("Would %s interrupted %s of %s", verb, j.Action, j.Module))
+	}
+	return report, nil
+}
+
+// journalSteps rebuilds the step list an interrupted transaction was running.
+func journalSteps(ctx context.Context, modules []models.Module, j *transaction.Journal) ([]transaction.Step, error) {
+	if j.Module == goToolsJournal {
+		return GoToolsMigrationSteps(ctx, modules), nil
+	}
+	m := FindModuleByKey(modules, j.Module)
+	if m == nil {
+		return nil, fmt.Errorf("interrupted %s of unknown module '%s'", j.Action, j.Module)
+	}
+
+	if j.Action == transaction.ActionUpgrade {
+		return UpgradeSteps(ctx, m, j.From, j.To)
+	}
+
+	provider, ok := m.(transaction.Provider)
+	if !ok {
+		return nil, fmt.Errorf("module '%s' cannot recover its steps", j.Module)
+	}
+	return provider.Steps(ctx, j.Action), nil
+}
+
+// relock brings code-template.lock in line with a resumed transaction.
+func relock(ctx context.Context, modules []models.Module, j *transaction.Journal) error {
+	if j.Module == goToolsJournal {
+		return relockGoTools(ctx, modules)
+	}
+	if j.Action == transaction.ActionUninstall {
+		return lockfile.Remove(j.Module)
+	}
+	return RecordLock(ctx, FindModuleByKey(modules, j.Module))
+}
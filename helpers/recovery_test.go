@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanRecovery_ReportsWithoutTouchingJournals(t *testing.T) {
+	t.Chdir(t.TempDir())
+	journal := filepath.Join(".code-template", "journal", "mod.json")
+	if err := os.MkdirAll(filepath.Dir(journal), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journal, []byte(`{"module":"mod","action":"install","completed":["one"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := PlanRecovery(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report) != 1 || report[0] != "Would roll back interrupted install of mod" {
+		t.Errorf("unexpected report %v", report)
+	}
+	if _, err := os.Stat(journal); err != nil {
+		t.Errorf("expected the journal to be left alone: %v", err)
+	}
+}
@@ -1,14 +1,18 @@
 package taskfile
 
 import (
+	"os"
+
+	"code-template/helpers/project"
 	yamlhelper "code-template/helpers/yaml"
 )
 
-const taskfilePath = "Taskfile.yml"
+// Path is the Taskfile managed by task modules.
+const Path = "Taskfile.yml"
 
 // HasTask checks if Taskfile.yml has a specific task.
 func HasTask(taskName string) (bool, error) {
-	data, err := yamlhelper.ReadYAML(taskfilePath)
+	data, err := yamlhelper.ReadYAML(Path)
 	if err != nil {
 		return false, err
 	}
@@ -22,10 +26,36 @@ func HasTask(taskName string) (bool, error) {
 	return exists, nil
 }
 
+// GetTask returns the definition of a task in Taskfile.yml, as decoded YAML.
+func GetTask(taskName string) (any, bool, error) {
+	data, err := yamlhelper.ReadYAML(Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tasks, ok := data["tasks"].(map[string]any)
+	if !ok {
+		return nil, false, nil
+	}
+
+	task, exists := tasks[taskName]
+	return task, exists, nil
+}
+
 // AddTask adds a task to Taskfile.yml.
 // Creates the file with version "3" if it doesn't exist.
 func AddTask(taskName, description string, commands []string) error {
-	data, err := yamlhelper.ReadYAML(taskfilePath)
+	return PutTask(taskName, map[string]any{
+		"desc": description,
+		"cmds": commands,
+	})
+}
+
+// PutTask sets a task's definition in Taskfile.yml, replacing any existing one.
+// Creates the file with version "3" if it doesn't exist.
+func PutTask(taskName string, definition any) error {
+	defer project.Lock(Path)()
+	data, err := yamlhelper.ReadYAML(Path)
 	if err != nil {
 		return err
 	}
@@ -42,18 +72,16 @@ func AddTask(taskName, description string, commands []string) error {
 	}
 
 	// Add the task
-	tasks[taskName] = map[string]any{
-		"desc": description,
-		"cmds": commands,
-	}
+	tasks[taskName] = definition
 
 	data["tasks"] = tasks
-	return yamlhelper.WriteYAML(taskfilePath, data)
+	return yamlhelper.WriteYAML(Path, data)
 }
 
 // RemoveTask removes a task from Taskfile.yml.
 func RemoveTask(taskName string) error {
-	data, err := yamlhelper.ReadYAML(taskfilePath)
+	defer project.Lock(Path)()
+	data, err := yamlhelper.ReadYAML(Path)
 	if err != nil {
 		return err
 	}
@@ -72,5 +100,54 @@ func RemoveTask(taskName string) error {
 		data["tasks"] = tasks
 	}
 
-	return yamlhelper.WriteYAML(taskfilePath, data)
+	return yamlhelper.WriteYAML(Path, data)
+}
+
+// RewriteCommands replaces every task command in Taskfile.yml, whether a
+// plain string or the cmd of a command map, with what rewrite returns. The
+// file is only written when a command changed; a missing Taskfile.yml is
+// left alone.
+func RewriteCommands(rewrite func(cmd string) string) error {
+	defer project.Lock(Path)()
+	if _, err := os.Stat(project.Path(Path)); os.IsNotExist(err) {
+		return nil
+	}
+	data, err := yamlhelper.ReadYAML(Path)
+	if err != nil {
+		return err
+	}
+	tasks, ok := data["tasks"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	changed := false
+	for _, task := range tasks {
+		definition, ok := task.(map[string]any)
+		if !ok {
+			continue
+		}
+		cmds, ok := definition["cmds"].([]any)
+		if !ok {
+			continue
+		}
+		for i, c := range cmds {
+			switch c := c.(type) {
+			case string:
+				if r := rewrite(c); r != c {
+					cmds[i], changed = r, true
+				}
+			case map[string]any:
+				if cmd, ok := c["cmd"].(string); ok {
+					if r := rewrite(cmd); r != cmd {
+						c["cmd"], changed = r, true
+					}
+				}
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return yamlhelper.WriteYAML(Path, data)
 }
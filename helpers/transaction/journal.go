@@ -0,0 +1,194 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"code-template/helpers/project"
+)
+
+const journalDir = ".code-template/journal"
+
+// Journal records the progress of a transaction so that it can be resumed
+// or rolled back if the process dies part-way through.
+type Journal struct {
+	Module     string    `json:"module"`
+	Action     string    `json:"action"`
+	BestEffort bool      `json:"best_effort"`
+	From       int       `json:"from,omitempty"` // Upgrades only
+	To         int       `json:"to,omitempty"`   // Upgrades only
+	Started    time.Time `json:"started"`
+	Completed  []string  `json:"completed"` // Names of steps whose Do succeeded, in order
+}
+
+func newJournal(module, action string, bestEffort bool) *Journal {
+	return &Journal{
+		Module:     module,
+		Action:     action,
+		BestEffort: bestEffort,
+		Started:    time.Now(),
+		Completed:  []string{},
+	}
+}
+
+// Pending returns journals left behind by interrupted transactions.
+func Pending() ([]*Journal, error) {
+	entries, err := os.ReadDir(project.Path(journalDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var journals []*Journal
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(project.Path(filepath.Join(journalDir, entry.Name())))
+		if err != nil {
+			return nil, err
+		}
+		var j Journal
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, fmt.Errorf("parse journal %s: %w", entry.Name(), err)
+		}
+		journals = append(journals, &j)
+	}
+	return journals, nil
+}
+
+// Rollback undoes the completed steps recorded in the journal, newest first.
+// Steps are matched by name. The journal is updated after each undo and
+// removed once everything has been undone. A journal that can't be written
+// doesn't stop the undos: they are what leaves the project consistent.
+func (j *Journal) Rollback(steps []Step) error {
+	var errs []error
+	var saveErr error
+	for i := len(j.Completed) - 1; i >= 0; i-- {
+		step, ok := findStep(steps, j.Completed[i])
+		if ok && step.Undo != nil {
+			if err := step.Undo(); err != nil {
+				errs = append(errs, fmt.Errorf("undo %s: %w", step.Name, err))
+				continue
+			}
+		}
+		j.Completed = slices.Delete(j.Completed, i, i+1)
+		if err := j.save(); err != nil {
+			saveErr = fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("rollback incomplete: %w", errors.Join(append(errs, saveErr)...))
+	}
+	return j.remove()
+}
+
+// Resume runs the steps the journal has not completed yet, with the same
+// failure semantics as the original Run or RunBestEffort call.
+func (j *Journal) Resume(steps []Step) error {
+	var errs []error
+	for i, step := range steps {
+		if slices.Contains(j.Completed, step.Name) {
+			continue
+		}
+		if step.Skip != nil && step.Skip() {
+			continue
+		}
+		if err := step.Do(); err != nil {
+			stepErr := stepError(i, step, err)
+			if j.BestEffort {
+				errs = append(errs, stepErr)
+				continue
+			}
+			if rbErr := j.Rollback(steps); rbErr != nil {
+				stepErr.Err = errors.Join(err, rbErr)
+			}
+			return stepErr
+		}
+		j.Completed = append(j.Completed, step.Name)
+		if err := j.save(); err != nil {
+			stepErr := stepError(i, step, fmt.Errorf("write journal: %w", err))
+			if j.BestEffort {
+				return stepErr
+			}
+			if rbErr := j.Rollback(steps); rbErr != nil {
+				stepErr.Err = errors.Join(stepErr.Err, rbErr)
+			}
+			return stepErr
+		}
+	}
+
+	if err := j.remove(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Discard deletes the journal without undoing anything.
+func (j *Journal) Discard() error {
+	return j.remove()
+}
+
+func (j *Journal) path() string {
+	return project.Path(filepath.Join(journalDir, j.Module+".json"))
+}
+
+// dirMu serializes writing journals with removing them and their
+// directory, since modules installing in parallel each keep one and the
+// first to finish would otherwise remove the directory under the others.
+var dirMu sync.Mutex
+
+func (j *Journal) save() error {
+	dirMu.Lock()
+	defer dirMu.Unlock()
+	if err := os.MkdirAll(project.Path(journalDir), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(j.path(), data, 0644)
+}
+
+func (j *Journal) remove() error {
+	dirMu.Lock()
+	defer dirMu.Unlock()
+	err := os.Remove(j.path())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	cleanupEmptyDirs()
+	return nil
+}
+
+// cleanupEmptyDirs removes the journal directory and its parent if they
+// are empty, so a clean run leaves no trace in the repository.
+func cleanupEmptyDirs() {
+	for dir := journalDir; dir != "." && dir != ""; dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(project.Path(dir))
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		os.Remove(project.Path(dir))
+	}
+}
+
+func findStep(steps []Step, name string) (Step, bool) {
+	for _, step := range steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
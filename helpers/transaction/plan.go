@@ -0,0 +1,134 @@
+package transaction
+
+import (
+	"fmt"
+	"os"
+
+	"code-template/helpers/project"
+	"code-template/services"
+)
+
+// EffectKind classifies what a step does to the repository.
+type EffectKind int
+
+const (
+	EffectWrite   EffectKind = iota // Create or overwrite a whole file
+	EffectEdit                      // Change part of a file, creating it if needed
+	EffectDelete                    // Delete a file or directory
+	EffectCommand                   // Run an external command
+)
+
+// Effect declares one change a step makes, so it can be shown before it runs.
+type Effect struct {
+	Kind   EffectKind
+	Target string // File path, or command line for EffectCommand
+	Detail string // e.g. "set golangci: 1", or the working directory of a command
+}
+
+// WritesFile declares that a step writes path.
+func WritesFile(path string) Effect {
+	return Effect{Kind: EffectWrite, Target: path}
+}
+
+// EditsFile declares that a step changes part of path.
+func EditsFile(path, detail string) Effect {
+	return Effect{Kind: EffectEdit, Target: path, Detail: detail}
+}
+
+// DeletesFile declares that a step deletes path.
+func DeletesFile(path string) Effect {
+	return Effect{Kind: EffectDelete, Target: path}
+}
+
+// RunsCommand declares that a step runs command, optionally in dir.
+func RunsCommand(command, dir string) Effect {
+	return Effect{Kind: EffectCommand, Target: command, Detail: dir}
+}
+
+// InstallsGoTool declares what services.Go.Install does for pkg: build
+// binaryName into .bin/, or in tool mode record it in go.mod and go.sum.
+func InstallsGoTool(pkg services.Package, binaryName string) []Effect {
+	effects := []Effect{RunsCommand(services.Go.InstallCommand(pkg), "")}
+	if services.Go.ToolMode() {
+		return append(effects, EditsFile(services.GoModFile, "add tool "+binaryName), EditsFile(services.GoSumFile, "add checksums"))
+	}
+	return append(effects, WritesFile(services.Go.GetBinPath(binaryName)))
+}
+
+// UninstallsGoTool declares what services.Go.Uninstall does: delete
+// binaryName from .bin/, or in tool mode drop it from go.mod.
+func UninstallsGoTool(binaryName string) []Effect {
+	if services.Go.ToolMode() {
+		return []Effect{EditsFile(services.GoModFile, "remove tool "+binaryName)}
+	}
+	return []Effect{DeletesFile(services.Go.GetBinPath(binaryName))}
+}
+
+// PlannedEffect is an Effect resolved against the current state of the repo.
+type PlannedEffect struct {
+	Verb   string // "create", "overwrite", "edit", "delete" or "run"
+	Target string
+	Detail string
+}
+
+func (e PlannedEffect) String() string {
+	s := fmt.Sprintf("%-9s %s", e.Verb, e.Target)
+	if e.Detail != "" {
+		if e.Verb == "run" {
+			return s + " (in " + e.Detail + ")"
+		}
+		return s + ": " + e.Detail
+	}
+	return s
+}
+
+// PlannedStep describes a step without running it.
+type PlannedStep struct {
+	Number  int
+	Name    string
+	Skipped bool // Skip reported true, so the step would not run
+	Effects []PlannedEffect
+}
+
+// Plan evaluates each step's Skip condition and resolves its declared
+// effects. Nothing is written and no command is run.
+func Plan(steps []Step) []PlannedStep {
+	planned := make([]PlannedStep, 0, len(steps))
+	for i, step := range steps {
+		p := PlannedStep{
+			Number:  i + 1,
+			Name:    step.Name,
+			Skipped: step.Skip != nil && step.Skip(),
+		}
+		for _, effect := range step.Effects {
+			p.Effects = append(p.Effects, resolve(effect))
+		}
+		planned = append(planned, p)
+	}
+	return planned
+}
+
+func resolve(e Effect) PlannedEffect {
+	verb := "run"
+	switch e.Kind {
+	case EffectWrite:
+		verb = "create"
+		if exists(e.Target) {
+			verb = "overwrite"
+		}
+	case EffectEdit:
+		verb = "create"
+		if exists(e.Target) {
+			verb = "edit"
+		}
+	case EffectDelete:
+		verb = "delete"
+	case EffectCommand:
+	}
+	return PlannedEffect{Verb: verb, Target: e.Target, Detail: e.Detail}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(project.Path(path))
+	return err == nil
+}
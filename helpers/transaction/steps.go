@@ -0,0 +1,289 @@
+package transaction
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code-template/helpers/claude"
+	"code-template/helpers/gitignore"
+	"code-template/helpers/merge"
+	"code-template/helpers/project"
+	"code-template/helpers/taskfile"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+// Require returns a step that fails with err unless check reports true.
+func Require(name string, check func() bool, err error) Step {
+	return Step{
+		Name: name,
+		Do: func() error {
+			if !check() {
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// CheckRequirement returns a step that fails with a
+// *models.MissingRequirementError unless r is found.
+func CheckRequirement(r models.Requirement) Step {
+	return Step{
+		Name: "check " + r.Name + " is installed",
+		Do: func() error {
+			if !r.Found() {
+				return &models.MissingRequirementError{Name: r.Name, Hint: r.Hint}
+			}
+			return nil
+		},
+	}
+}
+
+// CheckRequirements returns a CheckRequirement step for each of reqs.
+func CheckRequirements(reqs []models.Requirement) []Step {
+	steps := make([]Step, 0, len(reqs))
+	for _, r := range reqs {
+		steps = append(steps, CheckRequirement(r))
+	}
+	return steps
+}
+
+// WriteFile returns a step that writes data to path, creating parent
+// directories as needed. Undo removes the file.
+func WriteFile(name, path string, data []byte) Step {
+	return Step{
+		Name: name,
+		Do: func() error {
+			path := project.Path(path)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(path, data, 0644)
+		},
+		Undo: func() error {
+			return removeIfExists(path)
+		},
+		Effects: []Effect{WritesFile(path)},
+	}
+}
+
+// RemoveFile returns a step that deletes path. A missing file is not an error.
+func RemoveFile(name, path string) Step {
+	return Step{
+		Name: name,
+		Do: func() error {
+			return removeIfExists(path)
+		},
+		Effects: []Effect{DeletesFile(path)},
+	}
+}
+
+// SetKey returns a step that sets a top-level key in a YAML file.
+// Undo removes the key.
+func SetKey(path, key string, value any) Step {
+	return Step{
+		Name: "add entry to " + filepath.Base(path),
+		Do: func() error {
+			return yamlhelper.SetKey(path, key, value)
+		},
+		Undo: func() error {
+			return yamlhelper.RemoveKey(path, key)
+		},
+		Effects: []Effect{EditsFile(path, fmt.Sprintf("set %s: %v", key, value))},
+	}
+}
+
+// ChangeKey returns a step that changes a top-level key in a YAML file from
+// one value to another. Undo restores the previous value.
+func ChangeKey(path, key string, from, to any) Step {
+	return Step{
+		Name: fmt.Sprintf("set %s to %v in %s", key, to, filepath.Base(path)),
+		Do: func() error {
+			return yamlhelper.SetKey(path, key, to)
+		},
+		Undo: func() error {
+			return yamlhelper.SetKey(path, key, from)
+		},
+		Effects: []Effect{EditsFile(path, fmt.Sprintf("set %s: %v (was %v)", key, to, from))},
+	}
+}
+
+// RemoveKey returns a step that removes a top-level key from a YAML file.
+func RemoveKey(path, key string) Step {
+	return Step{
+		Name: "remove entry from " + filepath.Base(path),
+		Do: func() error {
+			return yamlhelper.RemoveKey(path, key)
+		},
+		Effects: []Effect{EditsFile(path, "remove "+key)},
+	}
+}
+
+// AddTask returns a step that adds a task to Taskfile.yml. Undo removes it.
+func AddTask(taskName, description string, commands []string) Step {
+	return Step{
+		Name: "add " + taskName + " task to Taskfile.yml",
+		Do: func() error {
+			return taskfile.AddTask(taskName, description, commands)
+		},
+		Undo: func() error {
+			return taskfile.RemoveTask(taskName)
+		},
+		Effects: []Effect{EditsFile(taskfile.Path, fmt.Sprintf("add task %s (%s)", taskName, strings.Join(commands, "; ")))},
+	}
+}
+
+// RemoveTask returns a step that removes a task from Taskfile.yml.
+func RemoveTask(taskName string) Step {
+	return Step{
+		Name: "remove " + taskName + " task from Taskfile.yml",
+		Do: func() error {
+			return taskfile.RemoveTask(taskName)
+		},
+		Effects: []Effect{EditsFile(taskfile.Path, "remove task "+taskName)},
+	}
+}
+
+// UpdateTask returns a step that sets a task in Taskfile.yml to a new
+// definition. Undo puts back the previous definition, or removes the task if
+// it did not exist. That is only known within the process that ran the
+// step, so rolling back an interrupted transaction leaves the task as is.
+func UpdateTask(taskName, description string, commands []string) Step {
+	var previous any
+	existed, captured := false, false
+	return Step{
+		Name: "update " + taskName + " task in Taskfile.yml",
+		Do: func() error {
+			task, ok, err := taskfile.GetTask(taskName)
+			if err != nil {
+				return err
+			}
+			previous, existed, captured = task, ok, true
+			return taskfile.AddTask(taskName, description, commands)
+		},
+		Undo: func() error {
+			if !captured {
+				return nil
+			}
+			if !existed {
+				return taskfile.RemoveTask(taskName)
+			}
+			return taskfile.PutTask(taskName, previous)
+		},
+		Effects: []Effect{EditsFile(taskfile.Path, fmt.Sprintf("set task %s (%s)", taskName, strings.Join(commands, "; ")))},
+	}
+}
+
+// AddGitignoreEntry returns a step that adds entry to .gitignore. It is
+// skipped when the entry is already there. Undo removes it.
+func AddGitignoreEntry(entry string) Step {
+	return Step{
+		Name: "add " + entry + " to .gitignore",
+		Skip: func() bool { return gitignore.Has(entry) },
+		Do: func() error {
+			return gitignore.Add(entry)
+		},
+		Undo: func() error {
+			return gitignore.Remove(entry)
+		},
+		Effects: []Effect{EditsFile(gitignore.Path(), "add "+entry)},
+	}
+}
+
+// RemoveGitignoreEntry returns a step that removes entry from .gitignore.
+func RemoveGitignoreEntry(entry string) Step {
+	return Step{
+		Name: "remove " + entry + " from .gitignore",
+		Do: func() error {
+			return gitignore.Remove(entry)
+		},
+		Effects: []Effect{EditsFile(gitignore.Path(), "remove "+entry)},
+	}
+}
+
+// AddHook returns a step that adds a Claude hook to .claude/settings.json.
+// It is skipped when the hook is already configured. Undo removes it.
+func AddHook(hook claude.Hook) Step {
+	return Step{
+		Name: "add " + hookLabel(hook) + " hook to settings.json",
+		Skip: func() bool { return claude.HasHook(hook) },
+		Do: func() error {
+			return claude.AddHook(hook)
+		},
+		Undo: func() error {
+			return claude.RemoveHook(hook)
+		},
+		Effects: []Effect{EditsFile(claude.SettingsPath(), "add "+hookLabel(hook)+" hook")},
+	}
+}
+
+// RemoveHook returns a step that removes a Claude hook from .claude/settings.json.
+func RemoveHook(hook claude.Hook) Step {
+	return Step{
+		Name: "remove " + hookLabel(hook) + " hook from settings.json",
+		Do: func() error {
+			return claude.RemoveHook(hook)
+		},
+		Effects: []Effect{EditsFile(claude.SettingsPath(), "remove "+hookLabel(hook)+" hook")},
+	}
+}
+
+// hookLabel describes a hook as "<event>[<matcher>] <command>".
+func hookLabel(hook claude.Hook) string {
+	if hook.Matcher == "" {
+		return hook.Event + " " + hook.Command
+	}
+	return hook.Event + "[" + hook.Matcher + "] " + hook.Command
+}
+
+// MergeFile returns a step that three-way merges the shipped change from base
+// to next into the file at path, keeping local edits. Changes that cannot be
+// merged are left between conflict markers rather than failing the step.
+// A missing file is written from next. Undo puts back the pre-merge content,
+// or removes a file the step wrote. That is only known within the process
+// that ran the step, so rolling back an interrupted transaction leaves the
+// merged file as is.
+func MergeFile(path string, base, next []byte, label string) Step {
+	var original []byte
+	existed, captured := false, false
+	return Step{
+		Name: "merge " + path,
+		Do: func() error {
+			path := project.Path(path)
+			data, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return err
+				}
+				captured = true
+				return os.WriteFile(path, next, 0644)
+			}
+			if err != nil {
+				return err
+			}
+			original, existed, captured = data, true, true
+			result := merge.File(path, base, data, next, "local", label)
+			return os.WriteFile(path, result.Content, 0644)
+		},
+		Undo: func() error {
+			switch {
+			case !captured:
+				return nil
+			case !existed:
+				return removeIfExists(path)
+			}
+			return os.WriteFile(project.Path(path), original, 0644)
+		},
+		Effects: []Effect{EditsFile(path, "three-way merge with "+label)},
+	}
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(project.Path(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
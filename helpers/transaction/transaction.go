@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"code-template/models"
+	"code-template/services"
+)
+
+// Actions a transaction can perform. They name the journal entry so an
+// interrupted run can be matched back to its module's step list.
+const (
+	ActionInstall   = "install"
+	ActionUninstall = "uninstall"
+	ActionUpgrade   = "upgrade"
+)
+
+// Step is a single unit of work with an optional compensating action.
+type Step struct {
+	Name    string       // Short description shown in errors, e.g. "copy golangci.yml"
+	Skip    func() bool  // Optional; a skipped step is never undone
+	Do      func() error // Performs the step
+	Undo    func() error // Optional; reverses Do
+	Effects []Effect     // What Do changes, for dry runs
+}
+
+// Provider is implemented by modules whose install and uninstall run as
+// transactions. Steps must return the same step names on every call so a
+// journal written by one process can be recovered by the next. The steps
+// run their commands under ctx.
+type Provider interface {
+	GetKey() string
+	Steps(ctx context.Context, action string) []Step
+}
+
+// Upgrader is implemented by modules that can upgrade an installation in
+// place. UpgradeSteps returns the steps that move an installation from
+// version from to from+1, or false if the module has no such upgrade path.
+// A module at its first version needs no Upgrader.
+type Upgrader interface {
+	UpgradeSteps(ctx context.Context, from int) ([]Step, bool)
+}
+
+// ToolPinner is implemented by modules that install tools at pinned
+// versions. OutdatedTools describes each installed tool whose version
+// differs from the pinned one; ToolSteps reinstalls those tools and skips
+// the ones that match.
+type ToolPinner interface {
+	OutdatedTools() []string
+	ToolSteps(ctx context.Context) []Step
+}
+
+// Run executes steps in order. Each completed step is journaled to disk.
+// If a step fails, or journaling it does, the completed steps are undone in
+// reverse order and a *models.StepError describing the failed step is
+// returned. Once ctx is cancelled, Run stops before the next step and
+// rolls back, failing with services.ErrCancelled.
+func Run(ctx context.Context, module, action string, steps []Step) error {
+	return run(ctx, newJournal(module, action, false), steps)
+}
+
+// RunUpgrade is Run for an upgrade between two versions. The versions are
+// journaled so an interrupted upgrade can rebuild the same step chain.
+func RunUpgrade(ctx context.Context, module string, from, to int, steps []Step) error {
+	j := newJournal(module, ActionUpgrade, false)
+	j.From = from
+	j.To = to
+	return run(ctx, j, steps)
+}
+
+func run(ctx context.Context, j *Journal, steps []Step) error {
+	if err := j.save(); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+
+	for i, step := range steps {
+		if step.Skip != nil && step.Skip() {
+			continue
+		}
+		// A cancelled operation stops before its next step and rolls back
+		var err error
+		if ctx.Err() != nil {
+			err = services.ErrCancelled
+		} else {
+			err = step.Do()
+		}
+		if err == nil {
+			j.Completed = append(j.Completed, step.Name)
+			// A step the journal doesn't record couldn't be recovered
+			// after a crash, so it fails like the step itself
+			if err = j.save(); err != nil {
+				err = fmt.Errorf("write journal: %w", err)
+			}
+		}
+		if err != nil {
+			if rbErr := j.Rollback(steps); rbErr != nil {
+				err = errors.Join(err, rbErr)
+			}
+			return stepError(i, step, err)
+		}
+	}
+
+	return j.remove()
+}
+
+// RunBestEffort executes every step even if earlier ones fail, and never
+// undoes anything. It is used for uninstalls, where leaving a step behind
+// is better than reinstalling what was already removed. Uninstalls also
+// roll back cancelled operations, so cancelling doesn't stop them. The
+// returned error joins a *models.StepError for each failed step.
+func RunBestEffort(module, action string, steps []Step) error {
+	j := newJournal(module, action, true)
+	if err := j.save(); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+
+	var errs []error
+	for i, step := range steps {
+		if step.Skip != nil && step.Skip() {
+			continue
+		}
+		if err := step.Do(); err != nil {
+			errs = append(errs, stepError(i, step, err))
+			continue
+		}
+		j.Completed = append(j.Completed, step.Name)
+		if err := j.save(); err != nil {
+			return fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	if err := j.remove(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// stepError reports the failure of steps[i], numbering steps from 1 like
+// plans do.
+func stepError(i int, step Step, err error) *models.StepError {
+	return &models.StepError{Step: i + 1, Name: step.Name, Err: err}
+}
@@ -0,0 +1,234 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"code-template/helpers/taskfile"
+	"code-template/models"
+	"code-template/services"
+)
+
+func recordingSteps(log *[]string, failAt string) []Step {
+	var steps []Step
+	for _, name := range []string{"one", "two", "three"} {
+		steps = append(steps, Step{
+			Name: name,
+			Do: func() error {
+				if name == failAt {
+					return errors.New("boom")
+				}
+				*log = append(*log, "do "+name)
+				return nil
+			},
+			Undo: func() error {
+				*log = append(*log, "undo "+name)
+				return nil
+			},
+		})
+	}
+	return steps
+}
+
+func TestRun_UndoesCompletedStepsInReverseOnFailure(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	var log []string
+	err := Run(t.Context(), "mod", ActionInstall, recordingSteps(&log, "three"))
+
+	var stepErr *models.StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != 3 || stepErr.Name != "three" {
+		t.Fatalf("expected StepError for step 3, got %v", err)
+	}
+
+	want := []string{"do one", "do two", "undo two", "undo one"}
+	if len(log) != len(want) {
+		t.Fatalf("expected %v, got %v", want, log)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, log)
+		}
+	}
+
+	if _, err := os.Stat(journalDir); !os.IsNotExist(err) {
+		t.Error("expected journal directory to be cleaned up")
+	}
+}
+
+func TestRun_RollsBackWhenTheJournalCannotBeWritten(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// A directory where the journal goes makes every save fail
+	journal := filepath.Join(journalDir, "mod.json")
+	var log []string
+	steps := append([]Step{{
+		Name: "block journal",
+		Do:   func() error { os.Remove(journal); return os.Mkdir(journal, 0755) },
+		Undo: func() error { log = append(log, "undo block journal"); return os.Remove(journal) },
+	}}, recordingSteps(&log, "")...)
+
+	err := Run(t.Context(), "mod", ActionInstall, steps)
+	var stepErr *models.StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != 1 {
+		t.Fatalf("expected StepError for step 1, got %v", err)
+	}
+	if len(log) != 1 || log[0] != "undo block journal" {
+		t.Errorf("expected the unjournaled step undone and nothing after it run, got %v", log)
+	}
+}
+
+func TestRun_NumbersARequirementCheckAsStepOne(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	missing := models.Requirement{Name: "missing", Found: func() bool { return false }}
+	runs := map[string]func(string, string, []Step) error{
+		"Run": func(module, action string, steps []Step) error {
+			return Run(t.Context(), module, action, steps)
+		},
+		"RunBestEffort": RunBestEffort,
+	}
+	for name, run := range runs {
+		err := run("mod", ActionInstall, []Step{CheckRequirement(missing)})
+		var stepErr *models.StepError
+		if !errors.As(err, &stepErr) || stepErr.Step != 1 {
+			t.Errorf("%s: expected StepError for step 1, got %v", name, err)
+		}
+	}
+}
+
+func TestRun_RollsBackWhenOperationIsCancelled(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var log []string
+	steps := recordingSteps(&log, "")
+	do := steps[1].Do
+	steps[1].Do = func() error {
+		cancel()
+		return do()
+	}
+
+	err := Run(ctx, "mod", ActionInstall, steps)
+	if !errors.Is(err, services.ErrCancelled) {
+		t.Fatalf("expected ErrCancelled, got %v", err)
+	}
+	want := []string{"do one", "do two", "undo two", "undo one"}
+	if len(log) != len(want) {
+		t.Fatalf("expected %v, got %v", want, log)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, log)
+		}
+	}
+}
+
+func TestJournal_ResumeAfterCrash(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Simulate a process that died after completing step "one"
+	j := newJournal("mod", ActionInstall, false)
+	j.Completed = []string{"one"}
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := Pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected one pending journal, got %d (%v)", len(pending), err)
+	}
+
+	var log []string
+	if err := pending[0].Resume(recordingSteps(&log, "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log) != 2 || log[0] != "do two" || log[1] != "do three" {
+		t.Fatalf("expected remaining steps to run, got %v", log)
+	}
+
+	if pending, _ := Pending(); len(pending) != 0 {
+		t.Error("expected journal to be removed after resume")
+	}
+}
+
+func TestJournal_RollbackAfterCrash(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	j := newJournal("mod", ActionInstall, false)
+	j.Completed = []string{"one", "two"}
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _ := Pending()
+	var log []string
+	if err := pending[0].Rollback(recordingSteps(&log, "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(log) != 2 || log[0] != "undo two" || log[1] != "undo one" {
+		t.Fatalf("expected reverse undo, got %v", log)
+	}
+}
+
+func TestJournal_RollbackAfterCrashKeepsWhatItCannotRestore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := taskfile.AddTask("lint", "user's lint", []string{"make lint"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("config.yml", []byte("merged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new process rebuilds the steps, so their previous content is unknown
+	steps := []Step{
+		UpdateTask("lint", "lint", []string{"golangci-lint run"}),
+		MergeFile("config.yml", nil, []byte("shipped\n"), "mod v2"),
+	}
+	j := newJournal("mod", ActionUpgrade, false)
+	j.Completed = []string{steps[0].Name, steps[1].Name}
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, _ := Pending()
+	if err := pending[0].Rollback(steps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, _ := taskfile.HasTask("lint"); !ok {
+		t.Error("expected the lint task to be kept")
+	}
+	if data, _ := os.ReadFile("config.yml"); string(data) != "merged\n" {
+		t.Errorf("expected config.yml to be kept, got %q", data)
+	}
+}
+
+func TestRun_ParallelTransactionsKeepTheirJournals(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// Each finished transaction removes the journal directory once it is
+	// empty, while the others keep writing theirs
+	errs := make(chan error, 16)
+	var wg sync.WaitGroup
+	for i := range cap(errs) {
+		wg.Go(func() {
+			steps := recordingSteps(new([]string), "")
+			for range 100 {
+				if err := Run(t.Context(), fmt.Sprintf("mod%d", i), ActionInstall, steps); err != nil {
+					errs <- err
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
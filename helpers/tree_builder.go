@@ -85,6 +85,98 @@ func BuildTree(modules []models.Module) *models.TreeState {
 	return tree
 }
 
+// presetsCategory is the ID and name of the root category holding presets.
+const presetsCategory = "presets"
+
+// AddPresets adds a "presets" category at the top of the tree with a node
+// per preset. Each preset expands to its member modules. Presets naming
+// unknown modules are left out.
+func AddPresets(tree *models.TreeState, modules []models.Module, presets []models.Preset) {
+	root := &models.TreeNode{
+		ID:       presetsCategory,
+		Name:     presetsCategory,
+		Type:     models.NodeCategory,
+		Children: make([]*models.TreeNode, 0),
+	}
+
+	for i := range presets {
+		p := &presets[i]
+		members, err := PresetMembers(modules, *p)
+		if err != nil {
+			continue
+		}
+
+		presetNode := &models.TreeNode{
+			ID:       presetsCategory + "/" + p.Name,
+			Name:     p.Name,
+			Type:     models.NodePreset,
+			Depth:    1,
+			Children: make([]*models.TreeNode, 0, len(members)),
+			Preset:   p,
+			Parent:   root,
+		}
+		for _, m := range members {
+			presetNode.Children = append(presetNode.Children, &models.TreeNode{
+				ID:     presetNode.ID + "/" + m.GetPath(),
+				Name:   m.GetName(),
+				Type:   models.NodeModule,
+				Depth:  2,
+				Module: m,
+				Parent: presetNode,
+			})
+		}
+		root.Children = append(root.Children, presetNode)
+	}
+
+	if len(root.Children) == 0 {
+		return
+	}
+	tree.Roots = append([]*models.TreeNode{root}, tree.Roots...)
+	tree.RebuildFlatVisible()
+}
+
+// BuildWorkspaceTree creates a tree with a top-level node per workspace
+// member, each holding the module tree and presets. Every node records its
+// member so its state is read from that member's directory. A lone member
+// starts expanded.
+func BuildWorkspaceTree(modules []models.Module, presets []models.Preset, members []string) *models.TreeState {
+	tree := models.NewTreeState()
+	for _, member := range members {
+		sub := BuildTree(modules)
+		AddPresets(sub, modules, presets)
+
+		node := &models.TreeNode{
+			ID:       member,
+			Name:     member,
+			Type:     models.NodeMember,
+			Children: sub.Roots,
+			Member:   member,
+		}
+		for _, child := range sub.Roots {
+			child.Parent = node
+			moveUnderMember(child, member)
+		}
+		tree.Roots = append(tree.Roots, node)
+	}
+
+	if len(members) == 1 {
+		tree.SetExpanded(members[0], true)
+	}
+	tree.RebuildFlatVisible()
+	return tree
+}
+
+// moveUnderMember moves node and its descendants one level down, under a
+// workspace member, keeping their IDs unique across members.
+func moveUnderMember(node *models.TreeNode, member string) {
+	node.ID = member + ":" + node.ID
+	node.Depth++
+	node.Member = member
+	for _, child := range node.Children {
+		moveUnderMember(child, member)
+	}
+}
+
 // sortNodes sorts nodes alphabetically, with categories before modules.
 func sortNodes(nodes []*models.TreeNode) {
 	sort.Slice(nodes, func(i, j int) bool {
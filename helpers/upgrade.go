@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"code-template/helpers/merge"
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// NoUpgradePathError is returned when a module provides no upgrade steps for
+// one of the versions between the installed and the current version.
+type NoUpgradePathError struct {
+	Module string
+	From   int
+	To     int
+}
+
+func (e *NoUpgradePathError) Error() string {
+	return fmt.Sprintf("%s has no upgrade path from v%d to v%d", e.Module, e.From, e.To)
+}
+
+// UpgradeSteps chains a module's per-version upgrade steps from one version
+// to another (v1→v2→v3). Each hop ends with a step that records the new
+// version in code-template.yml, and hop step names are prefixed with the
+// versions so they stay unique within the journal. Managed files whose
+// shipped content changed are three-way merged after the last hop, and tools
+// not at their pinned versions are reinstalled. A hop the module has no
+// steps for fails the whole upgrade, rather than guessing what changed
+// between the versions.
+func UpgradeSteps(ctx context.Context, m models.Module, from, to int) ([]transaction.Step, error) {
+	upgrader, _ := m.(transaction.Upgrader)
+
+	var steps []transaction.Step
+	for v := from; v < to; v++ {
+		if upgrader == nil {
+			return nil, &NoUpgradePathError{Module: m.GetName(), From: v, To: v + 1}
+		}
+		hop, ok := upgrader.UpgradeSteps(ctx, v)
+		if !ok {
+			return nil, &NoUpgradePathError{Module: m.GetName(), From: v, To: v + 1}
+		}
+		for _, step := range hop {
+			step.Name = fmt.Sprintf("v%d→v%d: %s", v, v+1, step.Name)
+			steps = append(steps, step)
+		}
+		steps = append(steps, transaction.ChangeKey(codeTemplateFileName, m.GetKey(), v, v+1))
+	}
+	steps = append(steps, mergeSteps(m, from, to)...)
+	if pinner, ok := m.(transaction.ToolPinner); ok {
+		steps = append(steps, pinner.ToolSteps(ctx)...)
+	}
+	return steps, nil
+}
+
+// mergeSteps returns a merge step for each managed file whose shipped
+// content differs between two versions. If the module doesn't know what it
+// shipped in from, there is no merge base and any local copy that differs
+// from the new content ends up as one whole-file conflict.
+func mergeSteps(m models.Module, from, to int) []transaction.Step {
+	var shippedFrom, shippedTo []models.ManagedFile
+	switch fm := m.(type) {
+	case models.VersionedFileManager:
+		shippedFrom, shippedTo = fm.ManagedFilesAt(from), fm.ManagedFilesAt(to)
+	case models.FileManager:
+		if from == to {
+			return nil
+		}
+		shippedTo = fm.ManagedFiles()
+	default:
+		return nil
+	}
+
+	shipped := make(map[string][]byte)
+	for _, f := range shippedFrom {
+		shipped[f.Path] = f.Content
+	}
+
+	var steps []transaction.Step
+	label := fmt.Sprintf("%s v%d", m.GetName(), to)
+	for _, f := range shippedTo {
+		if base, ok := shipped[f.Path]; ok && bytes.Equal(base, f.Content) {
+			continue
+		}
+		steps = append(steps, transaction.MergeFile(f.Path, shipped[f.Path], f.Content, label))
+	}
+	return steps
+}
+
+// FileConflicts lists the unresolved conflict markers in a managed file.
+type FileConflicts struct {
+	Path  string
+	Lines []int // 1-based lines of each opening marker
+}
+
+func (c FileConflicts) String() string {
+	lines := make([]string, len(c.Lines))
+	for i, n := range c.Lines {
+		lines[i] = strconv.Itoa(n)
+	}
+	return fmt.Sprintf("%s: %d conflict(s) at line %s", c.Path, len(c.Lines), strings.Join(lines, ", "))
+}
+
+// FindConflicts returns the managed files of m that still contain conflict
+// markers, e.g. after an update could not merge local edits cleanly.
+func FindConflicts(m models.Module) []FileConflicts {
+	fm, ok := m.(models.FileManager)
+	if !ok {
+		return nil
+	}
+
+	var conflicts []FileConflicts
+	for _, f := range fm.ManagedFiles() {
+		data, err := os.ReadFile(project.Path(f.Path))
+		if err != nil {
+			continue
+		}
+		if lines := merge.FindMarkers(data); len(lines) > 0 {
+			conflicts = append(conflicts, FileConflicts{Path: f.Path, Lines: lines})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,170 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"code-template/helpers/transaction"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+)
+
+type upgradableModule struct {
+	fakeModule
+	version int
+	hops    map[int][]transaction.Step
+}
+
+func (m *upgradableModule) GetVersion() int { return m.version }
+
+func (m *upgradableModule) UpgradeSteps(_ context.Context, from int) ([]transaction.Step, bool) {
+	steps, ok := m.hops[from]
+	return steps, ok
+}
+
+func TestUpdateModule_ChainsUpgradeSteps(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	step := func(name string) transaction.Step {
+		return transaction.Step{Name: name, Do: func() error { ran = append(ran, name); return nil }}
+	}
+	m := &upgradableModule{
+		fakeModule: fakeModule{key: "mod", installed: true},
+		version:    3,
+		hops: map[int][]transaction.Step{
+			1: {step("a")},
+			2: {step("b")},
+		},
+	}
+
+	if err := UpdateModule(t.Context(), m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ran) != 2 || ran[0] != "a" || ran[1] != "b" {
+		t.Fatalf("expected hops to run in order, got %v", ran)
+	}
+	if v := GetInstalledVersion(m); v != 3 {
+		t.Errorf("expected installed version 3, got %d", v)
+	}
+}
+
+func TestUpdateModule_FailureKeepsPreviousVersion(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	undone := false
+	m := &upgradableModule{
+		fakeModule: fakeModule{key: "mod", installed: true},
+		version:    3,
+		hops: map[int][]transaction.Step{
+			1: {{Name: "a", Do: func() error { return nil }, Undo: func() error { undone = true; return nil }}},
+			2: {{Name: "b", Do: func() error { return errors.New("boom") }}},
+		},
+	}
+
+	if err := UpdateModule(t.Context(), m); err == nil {
+		t.Fatal("expected error")
+	}
+	if !undone {
+		t.Error("expected the v1→v2 step to be undone")
+	}
+	if v := GetInstalledVersion(m); v != 1 {
+		t.Errorf("expected installed version to stay 1, got %d", v)
+	}
+}
+
+func TestUpdateModule_NoUpgradePath(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &upgradableModule{fakeModule: fakeModule{key: "mod", installed: true}, version: 2}
+
+	var noPath *NoUpgradePathError
+	if err := UpdateModule(t.Context(), m); !errors.As(err, &noPath) {
+		t.Fatalf("expected NoUpgradePathError, got %v", err)
+	}
+}
+
+type versionedModule struct {
+	upgradableModule
+	shipped map[int][]models.ManagedFile
+}
+
+func (m *versionedModule) ManagedFiles() []models.ManagedFile { return m.shipped[m.version] }
+
+func (m *versionedModule) ManagedFilesAt(version int) []models.ManagedFile {
+	return m.shipped[version]
+}
+
+func TestUpdateModule_MergesManagedFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "mod", 1); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("clean.txt", []byte("a\nlocal\nc\nd\ne\n"), 0644)
+	os.WriteFile("conflict.txt", []byte("x: local\n"), 0644)
+
+	m := &versionedModule{
+		upgradableModule: upgradableModule{
+			fakeModule: fakeModule{key: "mod", installed: true},
+			version:    2,
+			hops:       map[int][]transaction.Step{1: nil},
+		},
+		shipped: map[int][]models.ManagedFile{
+			1: {
+				{Path: "clean.txt", Content: []byte("a\nb\nc\nd\ne\n")},
+				{Path: "conflict.txt", Content: []byte("x: 1\n")},
+			},
+			2: {
+				{Path: "clean.txt", Content: []byte("a\nb\nc\nd\nshipped\n")},
+				{Path: "conflict.txt", Content: []byte("x: 2\n")},
+			},
+		},
+	}
+
+	if err := UpdateModule(t.Context(), m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("clean.txt"); string(data) != "a\nlocal\nc\nd\nshipped\n" {
+		t.Errorf("expected both edits in clean.txt, got:\n%s", data)
+	}
+	conflicts := FindConflicts(m)
+	if len(conflicts) != 1 || conflicts[0].Path != "conflict.txt" {
+		t.Errorf("expected conflict.txt to be reported, got %v", conflicts)
+	}
+}
+
+func TestUpdateModule_FailsWithoutUpgradeSteps(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := yamlhelper.SetKey(codeTemplateFileName, "plain", 1); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("plain.txt", []byte("local\n"), 0644)
+
+	// A version bump without upgrade steps is not guessed at
+	plain := &bumpedModule{steppedModule{fakeModule{key: "plain", installed: true}}}
+	var noPath *NoUpgradePathError
+	if err := UpdateModule(t.Context(), plain); !errors.As(err, &noPath) {
+		t.Fatalf("expected NoUpgradePathError, got %v", err)
+	}
+	if data, _ := os.ReadFile("plain.txt"); string(data) != "local\n" || GetInstalledVersion(plain) != 1 {
+		t.Errorf("expected plain to be left at v1, got %q at v%d", data, GetInstalledVersion(plain))
+	}
+}
+
+// bumpedModule is a steppedModule at version 2 with no upgrade steps.
+type bumpedModule struct {
+	steppedModule
+}
+
+func (m *bumpedModule) GetVersion() int { return 2 }
@@ -0,0 +1,167 @@
+// Package workspace supports monorepos whose root code-template.yml lists
+// member directories:
+//
+//	workspace:
+//	  members:
+//	    - apps/api
+//	    - apps/desktop
+//
+// Each member keeps its own module state (code-template.yml, Taskfile.yml,
+// code-template.lock and managed files) while .bin/ and .gitignore are
+// shared at the workspace root.
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"code-template/helpers/project"
+	yamlhelper "code-template/helpers/yaml"
+)
+
+const workspaceKey = "workspace"
+
+// InvalidWorkspaceError is returned when the workspace section of
+// code-template.yml is malformed or names a member that doesn't exist.
+type InvalidWorkspaceError struct {
+	File   string
+	Reason string
+}
+
+func (e *InvalidWorkspaceError) Error() string {
+	return fmt.Sprintf("workspace in %s: %s", e.File, e.Reason)
+}
+
+// UnknownMemberError is returned when a member is selected that the
+// workspace doesn't list.
+type UnknownMemberError struct {
+	Member string
+}
+
+func (e *UnknownMemberError) Error() string {
+	return fmt.Sprintf("'%s' is not a workspace member", e.Member)
+}
+
+// Members returns the members listed in the workspace root's
+// code-template.yml, in order, or nil when it defines no workspace.
+func Members() ([]string, error) {
+	return readMembers(filepath.Join(project.WorkspaceRoot(), project.ConfigFile))
+}
+
+// Find returns the member of members that dir, relative to the workspace
+// root, lies in, or "" when it lies in none.
+func Find(members []string, dir string) string {
+	dir = path.Clean(filepath.ToSlash(dir))
+	for _, m := range members {
+		if dir == m || strings.HasPrefix(dir, m+"/") {
+			return m
+		}
+	}
+	return ""
+}
+
+// Lookup returns the member of members named name, which may be written
+// with OS separators or a trailing slash.
+func Lookup(members []string, name string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(name))
+	for _, m := range members {
+		if m == clean {
+			return m, nil
+		}
+	}
+	return "", &UnknownMemberError{Member: name}
+}
+
+// Locate walks up from dir looking for a workspace that contains it. It
+// returns the workspace root and the member dir lies in ("" at the
+// workspace root itself), or ok false when dir is not inside a workspace.
+func Locate(dir string) (root, member string, ok bool, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", false, err
+	}
+	for current := dir; ; current = filepath.Dir(current) {
+		members, err := readMembers(filepath.Join(current, project.ConfigFile))
+		if err != nil {
+			return "", "", false, err
+		}
+		if members != nil {
+			rel, err := filepath.Rel(current, dir)
+			if err != nil {
+				return "", "", false, err
+			}
+			return current, Find(members, rel), true, nil
+		}
+		if filepath.Dir(current) == current {
+			return "", "", false, nil
+		}
+	}
+}
+
+// InstalledElsewhere reports whether a member other than the current one
+// has the module installed. Uninstalls use it to leave shared resources in
+// place while another member still needs them.
+func InstalledElsewhere(key string) bool {
+	members, err := Members()
+	if err != nil {
+		return false
+	}
+	for _, m := range members {
+		if m == project.Member() {
+			continue
+		}
+		config := filepath.Join(project.WorkspaceRoot(), filepath.FromSlash(m), project.ConfigFile)
+		if has, err := yamlhelper.HasKey(config, key); err == nil && has {
+			return true
+		}
+	}
+	return false
+}
+
+// readMembers parses the workspace section of a code-template.yml. A
+// missing file or section yields no members.
+func readMembers(file string) ([]string, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil, nil
+	}
+	value, exists, err := yamlhelper.GetValue(file, workspaceKey)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	section, ok := value.(map[string]any)
+	if !ok {
+		return nil, &InvalidWorkspaceError{File: file, Reason: "expected a map with \"members\""}
+	}
+	list, ok := section["members"].([]any)
+	if !ok || len(list) == 0 {
+		return nil, &InvalidWorkspaceError{File: file, Reason: "\"members\" must be a non-empty list of directories"}
+	}
+
+	root := filepath.Dir(file)
+	seen := make(map[string]bool)
+	members := make([]string, 0, len(list))
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return nil, &InvalidWorkspaceError{File: file, Reason: fmt.Sprintf("member %v is not a directory name", item)}
+		}
+		member := path.Clean(filepath.ToSlash(name))
+		if path.IsAbs(member) || member == "." || member == ".." || strings.HasPrefix(member, "../") {
+			return nil, &InvalidWorkspaceError{File: file, Reason: fmt.Sprintf("member %s must be a directory inside the workspace", name)}
+		}
+		if seen[member] {
+			return nil, &InvalidWorkspaceError{File: file, Reason: fmt.Sprintf("member %s is listed twice", name)}
+		}
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(member)))
+		if err != nil || !info.IsDir() {
+			return nil, &InvalidWorkspaceError{File: file, Reason: fmt.Sprintf("member %s is not a directory", name)}
+		}
+		seen[member] = true
+		members = append(members, member)
+	}
+	return members, nil
+}
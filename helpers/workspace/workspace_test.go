@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code-template/helpers/project"
+)
+
+// newWorkspace creates a workspace with the given members and config and
+// makes it the project root.
+func newWorkspace(t *testing.T, config string, members ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, m := range members {
+		if err := os.MkdirAll(filepath.Join(dir, m), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, project.ConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := project.SetRoot(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { project.SetRoot(".") })
+	return dir
+}
+
+func TestLocate_FindsMemberOfEnclosingWorkspace(t *testing.T) {
+	dir := newWorkspace(t, "workspace:\n  members: [apps/api, apps/web]\n", "apps/api/internal", "apps/web")
+	// A member's own config doesn't hide the workspace above it
+	if err := os.WriteFile(filepath.Join(dir, "apps", "api", project.ConfigFile), []byte("golangci: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root, member, ok, err := Locate(filepath.Join(dir, "apps", "api", "internal"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok || root != dir || member != "apps/api" {
+		t.Errorf("got (%s, %s, %v), want (%s, apps/api, true)", root, member, ok, dir)
+	}
+
+	_, member, ok, _ = Locate(dir)
+	if !ok || member != "" {
+		t.Errorf("at the workspace root got (%s, %v)", member, ok)
+	}
+}
+
+func TestMembers_RejectsMissingDirectory(t *testing.T) {
+	newWorkspace(t, "workspace:\n  members: [apps/api, apps/gone]\n", "apps/api")
+
+	_, err := Members()
+	var invalid *InvalidWorkspaceError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidWorkspaceError, got %v", err)
+	}
+}
+
+func TestInstalledElsewhere_IgnoresCurrentMember(t *testing.T) {
+	dir := newWorkspace(t, "workspace:\n  members: [api, web]\n", "api", "web")
+	if err := os.WriteFile(filepath.Join(dir, "api", project.ConfigFile), []byte("golangci: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project.UseMember("api")
+	if InstalledElsewhere("golangci") {
+		t.Error("api's own installation counted as another member's")
+	}
+	project.UseMember("web")
+	if !InstalledElsewhere("golangci") {
+		t.Error("expected api's installation to be seen from web")
+	}
+}
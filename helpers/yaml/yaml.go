@@ -4,11 +4,13 @@ import (
 	"os"
 
 	"github.com/goccy/go-yaml"
+
+	"code-template/helpers/project"
 )
 
 // ReadYAML reads a YAML file into a map, returning empty map if file doesn't exist.
 func ReadYAML(path string) (map[string]any, error) {
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(project.Path(path))
 	if os.IsNotExist(err) {
 		return make(map[string]any), nil
 	}
@@ -29,13 +31,13 @@ func ReadYAML(path string) (map[string]any, error) {
 	return result, nil
 }
 
-// WriteYAML writes a map to a YAML file.
+// WriteYAML writes a map to a YAML file, replacing it atomically.
 func WriteYAML(path string, data map[string]any) error {
 	out, err := yaml.Marshal(data)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, out, 0644)
+	return project.WriteFile(path, out, 0644)
 }
 
 // HasKey checks if a YAML file contains a specific top-level key.
@@ -61,6 +63,7 @@ func GetValue(path string, key string) (any, bool, error) {
 
 // SetKey sets a top-level key in a YAML file, preserving other keys.
 func SetKey(path string, key string, value any) error {
+	defer project.Lock(path)()
 	data, err := ReadYAML(path)
 	if err != nil {
 		return err
@@ -71,6 +74,7 @@ func SetKey(path string, key string, value any) error {
 
 // RemoveKey removes a top-level key from a YAML file.
 func RemoveKey(path string, key string) error {
+	defer project.Lock(path)()
 	data, err := ReadYAML(path)
 	if err != nil {
 		return err
@@ -1,32 +1,72 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"code-template/autoinit"
 	"code-template/helpers"
+	"code-template/helpers/project"
+	"code-template/helpers/workspace"
 	"code-template/models"
+	"code-template/services"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/goccy/go-yaml"
 )
 
 // Messages for async operations
 type installResultMsg struct {
 	moduleName string
-	success    bool
-	action     string // "install", "uninstall", or "update"
+	action     string                         // "install", "uninstall", "update", "restore", "preset", or "undo"
+	affected   []string                       // Other modules installed/uninstalled alongside
+	conflicts  []helpers.FileConflicts        // Files an update left conflict markers in
+	report     string                         // Per-module report of a preset install
+	logPath    string                         // Log of the commands run, "" if none ran
+	states     map[*models.TreeNode]nodeState // Node states once the operation is done
+	err        error                          // nil on success
 }
 
+// progressMsg reports a module of the running operation starting or
+// finishing.
+type progressMsg struct {
+	module  string
+	outcome string // helpers.OutcomeRunning or how the module ended
+}
+
+// logMsg is a line of output from a command of the running operation.
+type logMsg struct {
+	line string
+}
+
+// logWidth and logHeight size the log pane.
+const (
+	logWidth  = 96
+	logHeight = 12
+)
+
+// maxLogLines is how many lines of command output the log pane keeps,
+// across operations.
+const maxLogLines = 2000
+
 // Style definitions
 var (
 	primaryColor   = lipgloss.Color("#7D56F4")
 	secondaryColor = lipgloss.Color("#04B575")
 	warningColor   = lipgloss.Color("#FF6B6B")
+	modifiedColor  = lipgloss.Color("#FFB86C")
 	subtleColor    = lipgloss.Color("#626262")
 
 	titleStyle = lipgloss.NewStyle().
@@ -56,6 +96,10 @@ var (
 				Foreground(warningColor).
 				Bold(true)
 
+	checkboxModified = lipgloss.NewStyle().
+				Foreground(modifiedColor).
+				Bold(true)
+
 	checkboxNotInstalled = lipgloss.NewStyle().
 				Foreground(subtleColor)
 
@@ -84,18 +128,137 @@ var (
 
 	collapsedStyle = lipgloss.NewStyle().
 			Foreground(subtleColor)
+
+	confirmTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(primaryColor)
+
+	planStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(subtleColor).
+			Padding(0, 1)
 )
 
 type ViewModel struct {
+	Modules        []models.Module
 	Tree           *models.TreeState
 	SelectedIdx    int
 	StatusMessage  string
 	StatusIsError  bool
 	IsLoading      bool
 	LoadingMessage string
+	Confirm        *confirmation                  // Pending operation awaiting y/n
+	states         map[*models.TreeNode]nodeState // What the tree shows, see readStates
+	progress       []progressMsg                  // Latest outcome per module of the running operation
+	events         chan tea.Msg                   // progressMsg and logMsg of the running operation
+	cancel         context.CancelFunc             // Cancels the running operation
+	logLines       []string                       // Output of the commands run so far
+	showLog        bool                           // Whether the log pane is shown
+	logView        viewport.Model
 	spinner        spinner.Model
 }
 
+// nodeState is what the tree shows for a node. It is read when the TUI
+// starts and after each operation rather than on every frame, which would
+// run each installed module's tools to ask their versions many times a
+// second while the spinner ticks.
+type nodeState struct {
+	state     helpers.ModuleState
+	outdated  string // How an outdated module differs, see helpers.DescribeOutdated
+	installed int    // Installed modules of a preset, category or member
+	total     int
+}
+
+// readStates reads the state of every node of tree from the member it
+// belongs to. It switches the current member, so it runs where the project
+// root is owned: before the TUI starts and on an operation's own goroutine
+// once the operation is done, never on the UI goroutine while one runs.
+func readStates(tree *models.TreeState) map[*models.TreeNode]nodeState {
+	defer project.UseMember(project.Member())
+	states := make(map[*models.TreeNode]nodeState)
+	var visit func(node *models.TreeNode)
+	visit = func(node *models.TreeNode) {
+		project.UseMember(node.Member)
+		var s nodeState
+		if node.Type == models.NodeModule {
+			s.state = helpers.GetModuleState(node.Module)
+			if s.state == helpers.StateOutdated {
+				s.outdated = helpers.DescribeOutdated(node.Module)
+			}
+		} else {
+			s.installed, s.total = node.GetInstalledCount()
+		}
+		states[node] = s
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, root := range tree.Roots {
+		visit(root)
+	}
+	return states
+}
+
+// confirmation is an operation whose plan is shown before it runs.
+type confirmation struct {
+	title          string
+	plan           string
+	loadingMessage string
+	name           string // Module or preset the operation works on, naming its log
+	member         string // Workspace member the operation runs in
+	run            func(ctx context.Context, progress helpers.ProgressFunc) tea.Msg
+}
+
+// waitForEvent delivers the next progress or log event of the running
+// operation, or nothing once the operation has finished.
+func waitForEvent(events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := <-events; ok {
+			return msg
+		}
+		return nil
+	}
+}
+
+// maxStderrLines limits how much command output the TUI shows for a failure.
+const maxStderrLines = 8
+
+// errorDetail renders err followed by the last maxLines lines of any
+// captured command stderr. A maxLines of 0 shows all output.
+func errorDetail(err error, maxLines int) string {
+	detail := err.Error()
+	stderr := services.Stderr(err)
+	if stderr == "" {
+		return detail
+	}
+	lines := strings.Split(stderr, "\n")
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return detail + "\n" + strings.Join(lines, "\n")
+}
+
+// conflictReport lists files an update could not merge cleanly.
+func conflictReport(conflicts []helpers.FileConflicts) string {
+	var sb strings.Builder
+	sb.WriteString("⚠ Local edits conflict with the update; resolve the <<<<<<< markers in:")
+	for _, c := range conflicts {
+		sb.WriteString("\n  " + c.String())
+	}
+	return sb.String()
+}
+
+// otherNames returns the names of modules in list other than target.
+func otherNames(list []models.Module, target models.Module) []string {
+	var names []string
+	for _, m := range list {
+		if m != target {
+			names = append(names, m.GetName())
+		}
+	}
+	return names
+}
+
 func (m ViewModel) Init() tea.Cmd {
 	return m.spinner.Tick
 }
@@ -114,10 +277,34 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case progressMsg:
+		if !m.IsLoading {
+			return m, nil
+		}
+		updated := false
+		for i := range m.progress {
+			if m.progress[i].module == msg.module {
+				m.progress[i], updated = msg, true
+			}
+		}
+		if !updated {
+			m.progress = append(m.progress, msg)
+		}
+		return m, waitForEvent(m.events)
+
+	case logMsg:
+		m.appendLog(msg.line)
+		return m, waitForEvent(m.events)
+
 	case installResultMsg:
 		m.IsLoading = false
 		m.LoadingMessage = ""
-		if msg.success {
+		m.progress = nil
+		m.cancel = nil
+		if msg.states != nil {
+			m.states = msg.states
+		}
+		if msg.err == nil {
 			switch msg.action {
 			case "install":
 				m.StatusMessage = fmt.Sprintf("✓ Installed %s", msg.moduleName)
@@ -125,6 +312,18 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.StatusMessage = fmt.Sprintf("✓ Uninstalled %s", msg.moduleName)
 			case "update":
 				m.StatusMessage = fmt.Sprintf("✓ Updated %s", msg.moduleName)
+			case "restore":
+				m.StatusMessage = fmt.Sprintf("✓ Restored %s", msg.moduleName)
+			case "preset":
+				m.StatusMessage = fmt.Sprintf("✓ Installed preset %s\n%s", msg.moduleName, strings.TrimRight(msg.report, "\n"))
+			case "undo":
+				m.StatusMessage = fmt.Sprintf("✓ Undid %s", msg.moduleName)
+			}
+			if len(msg.affected) > 0 {
+				m.StatusMessage += fmt.Sprintf(" (with %s)", strings.Join(msg.affected, ", "))
+			}
+			if len(msg.conflicts) > 0 {
+				m.StatusMessage += "\n" + conflictReport(msg.conflicts)
 			}
 		} else {
 			m.StatusIsError = true
@@ -135,13 +334,45 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.StatusMessage = fmt.Sprintf("✗ Failed to uninstall %s", msg.moduleName)
 			case "update":
 				m.StatusMessage = fmt.Sprintf("✗ Failed to update %s", msg.moduleName)
+			case "restore":
+				m.StatusMessage = fmt.Sprintf("✗ Failed to restore %s", msg.moduleName)
+			case "preset":
+				m.StatusMessage = fmt.Sprintf("✗ Failed to install preset %s\n%s", msg.moduleName, strings.TrimRight(msg.report, "\n"))
+			case "undo":
+				m.StatusMessage = fmt.Sprintf("✗ Failed to undo %s", msg.moduleName)
+			}
+			m.StatusMessage += "\n" + errorDetail(msg.err, maxStderrLines)
+			if msg.logPath != "" {
+				m.StatusMessage += "\nFull output in " + msg.logPath + " (L shows the log)"
 			}
 		}
 		return m, nil
 
 	case tea.KeyMsg:
-		// Block input while loading
+		// The log pane can be toggled and scrolled at any time
+		switch msg.String() {
+		case "L":
+			m.showLog = !m.showLog
+			return m, nil
+		case "pgup":
+			if m.showLog {
+				m.logView.HalfPageUp()
+				return m, nil
+			}
+		case "pgdown":
+			if m.showLog {
+				m.logView.HalfPageDown()
+				return m, nil
+			}
+		}
+
+		// Block input while loading, except ctrl+c, which cancels the
+		// operation and lets it roll back
 		if m.IsLoading {
+			if msg.String() == "ctrl+c" && m.cancel != nil {
+				m.cancel()
+				m.LoadingMessage = "Cancelling, rolling back..."
+			}
 			return m, nil
 		}
 
@@ -149,6 +380,57 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.StatusMessage = ""
 		m.StatusIsError = false
 
+		// The confirmation pane captures input until answered
+		if m.Confirm != nil {
+			switch msg.String() {
+			case "y", "enter":
+				m.IsLoading = true
+				m.LoadingMessage = m.Confirm.loadingMessage
+				name, member, run := m.Confirm.name, m.Confirm.member, m.Confirm.run
+				m.appendLog(fmt.Sprintf("── %s%s", m.Confirm.loadingMessage, inMember(member)))
+				m.Confirm = nil
+				events := make(chan tea.Msg)
+				m.events, m.progress = events, nil
+				ctx, cancel := context.WithCancel(context.Background())
+				m.cancel = cancel
+				tree := m.Tree
+				return m, tea.Batch(
+					func() tea.Msg {
+						defer close(events)
+						defer cancel()
+						project.UseMember(member)
+						// The log is closed before events, so every line is
+						// delivered before the result
+						log, err := services.OpenLog(name, func(line string) {
+							events <- logMsg{line: line}
+						})
+						if err == nil {
+							defer log.Close()
+							ctx = services.WithLog(ctx, log)
+						}
+						msg := run(ctx, func(mod models.Module, outcome string, _ error) {
+							events <- progressMsg{module: mod.GetName(), outcome: outcome}
+						})
+						if result, ok := msg.(installResultMsg); ok {
+							if log != nil && !log.Empty() {
+								result.logPath = log.Path
+							}
+							result.states = readStates(tree)
+							msg = result
+						}
+						return msg
+					},
+					waitForEvent(events),
+				)
+			case "n", "esc", "q":
+				m.Confirm = nil
+				m.StatusMessage = "Cancelled"
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
@@ -165,7 +447,7 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "right", "l":
 			node := m.getCurrentNode()
-			if node != nil && node.Type == models.NodeCategory {
+			if node != nil && node.IsExpandable() {
 				m.Tree.SetExpanded(node.ID, true)
 				m.Tree.RebuildFlatVisible()
 			}
@@ -173,8 +455,8 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "left", "h":
 			node := m.getCurrentNode()
 			if node != nil {
-				if node.Type == models.NodeCategory && m.Tree.IsExpanded(node.ID) {
-					// Collapse current category
+				if node.IsExpandable() && m.Tree.IsExpanded(node.ID) {
+					// Collapse current category or preset
 					m.Tree.SetExpanded(node.ID, false)
 					m.Tree.RebuildFlatVisible()
 				} else if node.Parent != nil {
@@ -201,11 +483,41 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if node == nil {
 				return m, nil
 			}
+			project.UseMember(node.Member)
 
-			if node.Type == models.NodeCategory {
+			if node.Type == models.NodeCategory || node.Type == models.NodeMember {
 				// Toggle expand/collapse
 				m.Tree.ToggleExpanded(node.ID)
 				m.Tree.RebuildFlatVisible()
+			} else if node.Type == models.NodePreset {
+				// Install every member of the preset as one operation
+				preset := *node.Preset
+				if helpers.IsPresetInstalled(m.Modules, preset) {
+					m.StatusMessage = fmt.Sprintf("Preset %s is already installed", preset.Name)
+					return m, nil
+				}
+				plans, err := helpers.PlanPreset(context.Background(), m.Modules, preset)
+				if err != nil {
+					m.StatusMessage = fmt.Sprintf("✗ Cannot install preset %s: %v", preset.Name, err)
+					m.StatusIsError = true
+					return m, nil
+				}
+				modules := m.Modules
+				m.Confirm = &confirmation{
+					title:          fmt.Sprintf("Install preset %s?", preset.Name),
+					name:           preset.Name,
+					plan:           helpers.FormatPlans(plans),
+					loadingMessage: fmt.Sprintf("Installing preset %s...", preset.Name),
+					member:         node.Member,
+					run: func(ctx context.Context, progress helpers.ProgressFunc) tea.Msg {
+						report, err := helpers.InstallPreset(ctx, modules, preset, progress)
+						msg := installResultMsg{moduleName: preset.Name, action: "preset", err: err}
+						if report != nil {
+							msg.report = report.String()
+						}
+						return msg
+					},
+				}
 			} else if node.Type == models.NodeModule && node.Module != nil {
 				// Install/update module
 				module := node.Module
@@ -214,47 +526,140 @@ func (m ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				switch state {
 				case helpers.StateNotInstalled:
-					m.IsLoading = true
-					m.LoadingMessage = fmt.Sprintf("Installing %s...", moduleName)
-					return m, func() tea.Msg {
-						success := module.Install()
-						return installResultMsg{
-							moduleName: moduleName,
-							success:    success,
-							action:     "install",
-						}
+					// Planning resolves dependencies up front so cycles and
+					// conflicts are reported before anything touches disk
+					plans, err := helpers.PlanInstall(context.Background(), m.Modules, module)
+					if err != nil {
+						m.StatusMessage = fmt.Sprintf("✗ Cannot install %s: %v", moduleName, err)
+						m.StatusIsError = true
+						return m, nil
+					}
+					modules := m.Modules
+					m.Confirm = &confirmation{
+						title:          fmt.Sprintf("Install %s?", moduleName),
+						name:           moduleName,
+						plan:           helpers.FormatPlans(plans),
+						loadingMessage: fmt.Sprintf("Installing %s...", moduleName),
+						member:         node.Member,
+						run: func(ctx context.Context, progress helpers.ProgressFunc) tea.Msg {
+							done, err := helpers.InstallWithDependencies(ctx, modules, module, progress)
+							return installResultMsg{
+								moduleName: moduleName,
+								action:     "install",
+								affected:   otherNames(done, module),
+								err:        err,
+							}
+						},
 					}
 				case helpers.StateOutdated:
-					m.IsLoading = true
-					m.LoadingMessage = fmt.Sprintf("Updating %s...", moduleName)
-					return m, func() tea.Msg {
-						success := helpers.UpdateModule(module)
-						return installResultMsg{
-							moduleName: moduleName,
-							success:    success,
-							action:     "update",
-						}
+					// Planned like an install, so requirements the new
+					// version gained are installed first
+					plans, err := helpers.PlanInstall(context.Background(), m.Modules, module)
+					if err != nil {
+						m.StatusMessage = fmt.Sprintf("✗ Cannot update %s: %v", moduleName, err)
+						m.StatusIsError = true
+						return m, nil
+					}
+					modules := m.Modules
+					m.Confirm = &confirmation{
+						title:          fmt.Sprintf("Update %s?", moduleName),
+						name:           moduleName,
+						plan:           helpers.FormatPlans(plans),
+						loadingMessage: fmt.Sprintf("Updating %s...", moduleName),
+						member:         node.Member,
+						run: func(ctx context.Context, progress helpers.ProgressFunc) tea.Msg {
+							done, err := helpers.InstallWithDependencies(ctx, modules, module, progress)
+							return installResultMsg{
+								moduleName: moduleName,
+								action:     "update",
+								affected:   otherNames(done, module),
+								conflicts:  helpers.FindConflicts(module),
+								err:        err,
+							}
+						},
+					}
+				case helpers.StateModified:
+					// Show what changed and offer to restore the shipped content
+					drifted, err := helpers.DetectDrift(module)
+					if err != nil {
+						m.StatusMessage = fmt.Sprintf("✗ Cannot check %s: %v", moduleName, err)
+						m.StatusIsError = true
+						return m, nil
+					}
+					m.Confirm = &confirmation{
+						title:          fmt.Sprintf("Restore %d modified file(s) of %s?", len(drifted), moduleName),
+						name:           moduleName,
+						plan:           helpers.FormatDrift(drifted),
+						loadingMessage: fmt.Sprintf("Restoring %s...", moduleName),
+						member:         node.Member,
+						run: func(context.Context, helpers.ProgressFunc) tea.Msg {
+							return installResultMsg{
+								moduleName: moduleName,
+								action:     "restore",
+								err:        helpers.RestoreFiles(drifted),
+							}
+						},
 					}
 				case helpers.StateUpToDate:
 					m.StatusMessage = fmt.Sprintf("%s is already up to date", moduleName)
 				}
 			}
 
-		case "delete", "backspace":
+		case "u":
+			// Undo the most recent operation of the selected member
+			member := ""
+			if node := m.getCurrentNode(); node != nil {
+				member = node.Member
+			}
+			project.UseMember(member)
+			entry, err := helpers.LastUndoable()
+			if err != nil {
+				m.StatusMessage = fmt.Sprintf("Cannot undo%s: %v", inMember(member), err)
+				m.StatusIsError = !errors.Is(err, helpers.ErrNothingToUndo)
+				return m, nil
+			}
+			label := entry.Action + " " + entry.Target
+			m.Confirm = &confirmation{
+				title:          fmt.Sprintf("Undo %s?", label),
+				plan:           formatUndo(entry),
+				loadingMessage: fmt.Sprintf("Undoing %s...", label),
+				name:           "undo",
+				member:         member,
+				run: func(context.Context, helpers.ProgressFunc) tea.Msg {
+					return installResultMsg{moduleName: label, action: "undo", err: helpers.Undo(entry)}
+				},
+			}
+
+		case "delete", "backspace", "D":
 			node := m.getCurrentNode()
 			if node != nil && node.Type == models.NodeModule && node.Module != nil {
+				project.UseMember(node.Member)
 				module := node.Module
 				if module.IsInstalled() {
-					m.IsLoading = true
 					moduleName := module.GetName()
-					m.LoadingMessage = fmt.Sprintf("Uninstalling %s...", moduleName)
-					return m, func() tea.Msg {
-						success := module.Uninstall()
-						return installResultMsg{
-							moduleName: moduleName,
-							success:    success,
-							action:     "uninstall",
-						}
+					cascade := msg.String() == "D"
+					plans, err := helpers.PlanUninstall(context.Background(), m.Modules, module, cascade)
+					if err != nil {
+						m.StatusMessage = fmt.Sprintf("✗ Cannot uninstall %s: %v (press D to uninstall dependents too)", moduleName, err)
+						m.StatusIsError = true
+						return m, nil
+					}
+					modules := m.Modules
+					m.Confirm = &confirmation{
+						title:          fmt.Sprintf("Uninstall %s?", moduleName),
+						name:           moduleName,
+						plan:           helpers.FormatPlans(plans),
+						loadingMessage: fmt.Sprintf("Uninstalling %s...", moduleName),
+						member:         node.Member,
+						run: func(ctx context.Context, _ helpers.ProgressFunc) tea.Msg {
+							done, err := helpers.UninstallWithDependents(ctx, modules, module, cascade)
+							return installResultMsg{
+								moduleName: moduleName,
+								action:     "uninstall",
+								affected:   otherNames(done, module),
+								err:        err,
+							}
+						},
 					}
 				}
 			}
@@ -271,16 +676,23 @@ func (m ViewModel) View() string {
 	content.WriteString(title + "\n\n")
 
 	// Render tree
-	for i, node := range m.Tree.FlatVisible {
-		line := m.renderNode(node, i == m.SelectedIdx)
-		content.WriteString(line + "\n")
-	}
+	content.WriteString(m.renderTree())
 
-	// Loading indicator or status message
-	if m.IsLoading {
+	// Confirmation pane, loading indicator or status message
+	if m.Confirm != nil {
+		content.WriteString("\n")
+		content.WriteString(confirmTitleStyle.Render(m.Confirm.title) + "\n")
+		content.WriteString(planStyle.Render(strings.TrimRight(m.Confirm.plan, "\n")))
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render("y/enter confirm • n/esc cancel"))
+		content.WriteString("\n")
+	} else if m.IsLoading {
 		content.WriteString("\n")
 		content.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), m.LoadingMessage))
 		content.WriteString("\n")
+		content.WriteString(m.renderProgress())
+		content.WriteString(helpStyle.Render("ctrl+c cancel"))
+		content.WriteString("\n")
 	} else if m.StatusMessage != "" {
 		content.WriteString("\n")
 		if m.StatusIsError {
@@ -291,17 +703,67 @@ func (m ViewModel) View() string {
 		content.WriteString("\n")
 	}
 
+	if m.showLog {
+		content.WriteString("\n")
+		content.WriteString(planStyle.Render(m.logView.View()))
+		content.WriteString("\n")
+	}
+
 	// Help text
 	content.WriteString("\n")
-	helpText := "↑/↓ navigate • →/l expand • ←/h collapse • enter install • del uninstall • q quit"
+	helpText := "↑/↓ navigate • →/l expand • ←/h collapse • enter install/update/restore • del uninstall • D uninstall with dependents • u undo • L log • q quit"
+	if m.showLog {
+		helpText += " • pgup/pgdown scroll log"
+	}
 	content.WriteString(helpStyle.Render(helpText))
 
 	// Wrap in container
 	return containerStyle.Render(content.String())
 }
 
+// appendLog adds a line to the log pane, following the output unless the
+// pane was scrolled up.
+func (m *ViewModel) appendLog(line string) {
+	follow := m.logView.AtBottom()
+	m.logLines = append(m.logLines, line)
+	if len(m.logLines) > maxLogLines {
+		m.logLines = m.logLines[len(m.logLines)-maxLogLines:]
+	}
+	m.logView.SetContent(strings.Join(m.logLines, "\n"))
+	if follow {
+		m.logView.GotoBottom()
+	}
+}
+
+// renderProgress renders a line per module the running operation has
+// started, with a spinner until the module finishes.
+func (m ViewModel) renderProgress() string {
+	var lines strings.Builder
+	for _, p := range m.progress {
+		mark := "✓"
+		switch p.outcome {
+		case helpers.OutcomeRunning:
+			mark = m.spinner.View()
+		case helpers.OutcomeFailed:
+			mark = "✗"
+		}
+		lines.WriteString(fmt.Sprintf("  %s %-22s %s\n", mark, p.module, p.outcome))
+	}
+	return lines.String()
+}
+
+// renderTree renders the visible nodes, one per line.
+func (m ViewModel) renderTree() string {
+	var tree strings.Builder
+	for i, node := range m.Tree.FlatVisible {
+		tree.WriteString(m.renderNode(node, i == m.SelectedIdx) + "\n")
+	}
+	return tree.String()
+}
+
 func (m ViewModel) renderNode(node *models.TreeNode, selected bool) string {
 	var line strings.Builder
+	state := m.states[node]
 
 	// Cursor indicator
 	if selected {
@@ -315,8 +777,34 @@ func (m ViewModel) renderNode(node *models.TreeNode, selected bool) string {
 		line.WriteString(m.getTreePrefix(node))
 	}
 
-	if node.Type == models.NodeCategory {
-		// Category node
+	if node.Type == models.NodePreset {
+		// Preset node: expandable, checked once every member is installed
+		var indicator string
+		if m.Tree.IsExpanded(node.ID) {
+			indicator = expandedStyle.Render("▼")
+		} else {
+			indicator = collapsedStyle.Render("▶")
+		}
+
+		var checkbox string
+		if state.installed == state.total {
+			checkbox = checkboxInstalled.Render("[✓]")
+		} else {
+			checkbox = checkboxNotInstalled.Render("[ ]")
+		}
+		countText := countStyle.Render(fmt.Sprintf("(%d/%d)", state.installed, state.total))
+
+		nodeContent := fmt.Sprintf("%s %s %s %s", indicator, checkbox, node.Name, countText)
+		if node.Preset.Description != "" {
+			nodeContent += versionStyle.Render(" " + node.Preset.Description)
+		}
+		if selected {
+			line.WriteString(selectedStyle.Render(nodeContent))
+		} else {
+			line.WriteString(nodeContent)
+		}
+	} else if node.Type == models.NodeCategory || node.Type == models.NodeMember {
+		// Category or workspace member node
 		var indicator string
 		if m.Tree.IsExpanded(node.ID) {
 			indicator = expandedStyle.Render("▼")
@@ -324,8 +812,7 @@ func (m ViewModel) renderNode(node *models.TreeNode, selected bool) string {
 			indicator = collapsedStyle.Render("▶")
 		}
 
-		installed, total := node.GetInstalledCount()
-		countText := countStyle.Render(fmt.Sprintf("(%d/%d)", installed, total))
+		countText := countStyle.Render(fmt.Sprintf("(%d/%d)", state.installed, state.total))
 
 		nodeContent := fmt.Sprintf("%s %s %s", indicator, node.Name, countText)
 		if selected {
@@ -336,19 +823,20 @@ func (m ViewModel) renderNode(node *models.TreeNode, selected bool) string {
 	} else {
 		// Module node
 		module := node.Module
-		state := helpers.GetModuleState(module)
 
 		var checkbox string
 		var versionText string
 
-		switch state {
+		switch state.state {
 		case helpers.StateUpToDate:
 			checkbox = checkboxInstalled.Render("[✓]")
 			versionText = versionStyle.Render(fmt.Sprintf(" (v%d)", module.GetVersion()))
 		case helpers.StateOutdated:
 			checkbox = checkboxOutdated.Render("[!]")
-			installedVer := helpers.GetInstalledVersion(module)
-			versionText = versionStyle.Render(fmt.Sprintf(" (v%d → v%d)", installedVer, module.GetVersion()))
+			versionText = versionStyle.Render(fmt.Sprintf(" (%s)", state.outdated))
+		case helpers.StateModified:
+			checkbox = checkboxModified.Render("[~]")
+			versionText = versionStyle.Render(fmt.Sprintf(" (v%d, modified)", module.GetVersion()))
 		case helpers.StateNotInstalled:
 			checkbox = checkboxNotInstalled.Render("[ ]")
 			versionText = ""
@@ -402,11 +890,28 @@ var (
 	versionFlag   string
 	listFlag      bool
 	debugTreeFlag bool
+	updateFlag    string
+	cascadeFlag   bool
+	dryRunFlag    bool
+	recoverFlag   string
+	statusFlag    bool
+	driftFlag     bool
+	restoreFlag   string
+	rootFlag      string
+	memberFlag    string
+	allFlag       bool
+	yesFlag       bool
+	noInputFlag   bool
+	adoptFlag     string
+	taskFlag      string
+	outputFlag    string
+	jobsFlag      int
+	olderThanFlag time.Duration
 )
 
 func init() {
-	flag.StringVar(&installFlag, "install", "", "Install a module by name")
-	flag.StringVar(&installFlag, "i", "", "Install a module by name (shorthand)")
+	flag.StringVar(&installFlag, "install", "", "Install a module or preset by name")
+	flag.StringVar(&installFlag, "i", "", "Install a module or preset by name (shorthand)")
 	flag.StringVar(&uninstallFlag, "uninstall", "", "Uninstall a module by name")
 	flag.StringVar(&uninstallFlag, "u", "", "Uninstall a module by name (shorthand)")
 	flag.StringVar(&versionFlag, "version", "", "Show version info for a module")
@@ -414,6 +919,101 @@ func init() {
 	flag.BoolVar(&listFlag, "list", false, "List all available modules")
 	flag.BoolVar(&listFlag, "l", false, "List all available modules (shorthand)")
 	flag.BoolVar(&debugTreeFlag, "debug-tree", false, "Debug: show tree structure")
+	flag.StringVar(&updateFlag, "update", "", "Update an installed module by name")
+	flag.BoolVar(&cascadeFlag, "cascade", false, "Also uninstall modules that depend on the target")
+	flag.BoolVar(&dryRunFlag, "dry-run", false, "Print what install, uninstall or update would do without doing it")
+	flag.StringVar(&recoverFlag, "recover", "rollback", "How to finish interrupted operations: rollback or resume")
+	flag.BoolVar(&statusFlag, "status", false, "Show the state of installed modules")
+	flag.BoolVar(&driftFlag, "drift", false, "With --status, show diffs of modified managed files and exit 1 if any")
+	flag.StringVar(&restoreFlag, "restore", "", "Restore a module's modified managed files")
+	flag.StringVar(&rootFlag, "root", "", "Project root (default: nearest directory with code-template.yml, go.mod or .git)")
+	flag.StringVar(&rootFlag, "C", "", "Project root (shorthand)")
+	flag.StringVar(&memberFlag, "member", "", "Workspace member to operate on, relative to the workspace root")
+	flag.BoolVar(&allFlag, "all", false, "Operate on every workspace member")
+	flag.BoolVar(&yesFlag, "yes", false, "Answer yes to every prompt; implies --no-input")
+	flag.StringVar(&outputFlag, "output", "text", "Output format of --list, --version, --status, --debug-tree, doctor and cache: text, json or yaml")
+	flag.StringVar(&outputFlag, "o", "text", "Output format (shorthand)")
+	flag.IntVar(&jobsFlag, "jobs", helpers.Jobs, "How many independent modules to install at once")
+	flag.IntVar(&jobsFlag, "j", helpers.Jobs, "How many independent modules to install at once (shorthand)")
+	flag.DurationVar(&olderThanFlag, "older-than", 30*24*time.Hour, "With cache prune, remove tools no repo installed for this long")
+	flag.BoolVar(&noInputFlag, "no-input", false, "Never prompt: prompts take their configured answers and log lines replace colored output (also "+autoinit.NonInteractiveEnv+"=1)")
+	flag.StringVar(&adoptFlag, "adopt", "", "Answer to adopting detected modules without prompting: yes or no (default: "+autoinit.DefaultsKey+"."+autoinit.PromptAdopt+" in code-template.yml)")
+	flag.StringVar(&taskFlag, "install-task", "", "Answer to installing go-task without prompting: yes or no (default: "+autoinit.DefaultsKey+"."+autoinit.PromptInstallTask+" in code-template.yml)")
+
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: code-template [command] [flags]\n\nCommands:\n")
+		for _, c := range commands {
+			fmt.Fprintf(out, "  %-14s %s\n", c.name, c.usage)
+		}
+		fmt.Fprintf(out, "\nWithout a command or flags the interactive UI starts.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+}
+
+// commands are run as "code-template <command> [flags]".
+var commands = []struct {
+	name        string
+	subcommands []string
+	usage       string
+}{
+	{"init", nil, "Set up code-template in the project"},
+	{"doctor", nil, "Check the tools and libraries code-template and its modules need"},
+	{"apply", nil, "Install, repair or update modules until the repo matches code-template.yml"},
+	{"sync", nil, "Same as apply"},
+	{"history", nil, "List the operations run in the project"},
+	{"undo", nil, "Revert the files changed by the most recent operation"},
+	{"migrate-tools", nil, "Move the Go tools in .bin/ to go.mod tool directives (go-tools: tool)"},
+	{"cache", []string{"list", "prune", "verify"}, "Manage the tool builds shared by every repo: cache list, prune or verify"},
+}
+
+// parseArgs parses the flags, which may come before and after a command,
+// and returns the command or "" when there is none, and its subcommand.
+func parseArgs() (string, string, error) {
+	flag.Parse()
+	command, subcommand := "", ""
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+		var err error
+		if subcommand, err = parseCommandFlags(command); err != nil {
+			return "", "", err
+		}
+	}
+	if outputFlag != "text" && outputFlag != "json" && outputFlag != "yaml" {
+		return "", "", fmt.Errorf("--output must be text, json or yaml, not %q", outputFlag)
+	}
+	if jobsFlag < 1 {
+		return "", "", fmt.Errorf("--jobs must be at least 1, not %d", jobsFlag)
+	}
+	helpers.Jobs = jobsFlag
+	return command, subcommand, nil
+}
+
+// parseCommandFlags checks command, and its subcommand if it takes one, are
+// known and parses the flags after them. It returns the subcommand.
+func parseCommandFlags(command string) (string, error) {
+	for _, c := range commands {
+		if c.name != command {
+			continue
+		}
+		subcommand := ""
+		if len(c.subcommands) > 0 {
+			if flag.NArg() < 2 || !slices.Contains(c.subcommands, flag.Arg(1)) {
+				return "", fmt.Errorf("%s needs one of %s", command, strings.Join(c.subcommands, ", "))
+			}
+			subcommand = flag.Arg(1)
+		}
+		args := flag.Args()[1:]
+		if subcommand != "" {
+			args = args[1:]
+		}
+		flag.CommandLine.Parse(args)
+		if flag.NArg() > 0 {
+			return "", fmt.Errorf("unexpected argument %q", flag.Arg(0))
+		}
+		return subcommand, nil
+	}
+	return "", fmt.Errorf("unknown command %q", command)
 }
 
 // findModule finds a module by name or key.
@@ -427,43 +1027,98 @@ func findModule(modules []models.Module, name string) models.Module {
 	return nil
 }
 
-// runInstall installs a module by name.
-func runInstall(modules []models.Module, name string) int {
+// runInstall installs a module or preset by name.
+func runInstall(ctx context.Context, modules []models.Module, presets []models.Preset, name string) int {
 	module := findModule(modules, name)
 	if module == nil {
-		fmt.Fprintf(os.Stderr, "Error: module '%s' not found\n", name)
-		fmt.Fprintln(os.Stderr, "Use --list to see available modules")
+		if preset := helpers.FindPreset(presets, name); preset != nil {
+			return runInstallPreset(ctx, modules, *preset)
+		}
+		fmt.Fprintf(os.Stderr, "Error: module or preset '%s' not found\n", name)
+		fmt.Fprintln(os.Stderr, "Use --list to see available modules and presets")
 		return 1
 	}
 
+	if dryRunFlag {
+		plans, err := helpers.PlanInstall(ctx, modules, module)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Cannot install '%s': %v\n", module.GetName(), err)
+			return 1
+		}
+		fmt.Print(helpers.FormatPlans(plans))
+		return 0
+	}
+
 	state := helpers.GetModuleState(module)
 	switch state {
 	case helpers.StateUpToDate:
 		fmt.Printf("Module '%s' is already installed (v%d)\n", module.GetName(), module.GetVersion())
 		return 0
+	case helpers.StateModified:
+		fmt.Printf("Module '%s' is already installed (v%d) but its managed files were modified\n", module.GetName(), module.GetVersion())
+		fmt.Printf("Use --restore %s to restore them\n", module.GetName())
+		return 0
 	case helpers.StateOutdated:
-		fmt.Printf("Updating '%s' from v%d to v%d...\n",
-			module.GetName(), helpers.GetInstalledVersion(module), module.GetVersion())
-		if helpers.UpdateModule(module) {
-			fmt.Printf("✓ Updated '%s' to v%d\n", module.GetName(), module.GetVersion())
-			return 0
+		return updateWithDependencies(ctx, modules, module)
+	case helpers.StateNotInstalled:
+		if _, err := helpers.InstallWithDependencies(ctx, modules, module, printProgress); err != nil {
+			// Requirements and conflicts are resolved before anything runs
+			var failed *helpers.InstallError
+			if !errors.As(err, &failed) {
+				fmt.Fprintf(os.Stderr, "✗ Cannot install '%s': %v\n", module.GetName(), err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "✗ Failed to install '%s': %s\n", module.GetName(), errorDetail(err, 0))
+			return 1
 		}
-		fmt.Fprintf(os.Stderr, "✗ Failed to update '%s'\n", module.GetName())
+		return 0
+	}
+	return 1
+}
+
+// updateWithDependencies updates an outdated module, installing any
+// requirements its new version gained first.
+func updateWithDependencies(ctx context.Context, modules []models.Module, module models.Module) int {
+	fmt.Printf("Updating '%s' (%s)...\n", module.GetName(), helpers.DescribeOutdated(module))
+	if _, err := helpers.InstallWithDependencies(ctx, modules, module, printProgress); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Failed to update '%s': %s\n", module.GetName(), errorDetail(err, 0))
 		return 1
-	case helpers.StateNotInstalled:
-		fmt.Printf("Installing '%s' v%d...\n", module.GetName(), module.GetVersion())
-		if module.Install() {
-			fmt.Printf("✓ Installed '%s' v%d\n", module.GetName(), module.GetVersion())
-			return 0
+	}
+	fmt.Printf("✓ Updated '%s' to v%d\n", module.GetName(), module.GetVersion())
+	if conflicts := helpers.FindConflicts(module); len(conflicts) > 0 {
+		fmt.Println(conflictReport(conflicts))
+	}
+	return 0
+}
+
+// runInstallPreset installs every module of a preset as one operation and
+// prints a combined report.
+func runInstallPreset(ctx context.Context, modules []models.Module, preset models.Preset) int {
+	if dryRunFlag {
+		plans, err := helpers.PlanPreset(ctx, modules, preset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Cannot install preset '%s': %v\n", preset.Name, err)
+			return 1
+		}
+		fmt.Print(helpers.FormatPlans(plans))
+		return 0
+	}
+
+	fmt.Printf("Installing preset '%s'...\n", preset.Name)
+	report, err := helpers.InstallPreset(ctx, modules, preset, printProgress)
+	if err != nil {
+		if report != nil {
+			fmt.Print(report.String())
 		}
-		fmt.Fprintf(os.Stderr, "✗ Failed to install '%s'\n", module.GetName())
+		fmt.Fprintf(os.Stderr, "✗ Failed to install preset '%s': %s\n", preset.Name, errorDetail(err, 0))
 		return 1
 	}
-	return 1
+	fmt.Printf("✓ Installed preset '%s'\n", preset.Name)
+	return 0
 }
 
 // runUninstall uninstalls a module by name.
-func runUninstall(modules []models.Module, name string) int {
+func runUninstall(ctx context.Context, modules []models.Module, name string) int {
 	module := findModule(modules, name)
 	if module == nil {
 		fmt.Fprintf(os.Stderr, "Error: module '%s' not found\n", name)
@@ -476,13 +1131,252 @@ func runUninstall(modules []models.Module, name string) int {
 		return 0
 	}
 
-	fmt.Printf("Uninstalling '%s'...\n", module.GetName())
-	if module.Uninstall() {
-		fmt.Printf("✓ Uninstalled '%s'\n", module.GetName())
+	order, err := helpers.ResolveUninstallOrder(modules, module, cascadeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot uninstall '%s': %v\n", module.GetName(), err)
+		fmt.Fprintln(os.Stderr, "Use --cascade to uninstall dependent modules as well")
+		return 1
+	}
+
+	if dryRunFlag {
+		plans, err := helpers.PlanUninstall(ctx, modules, module, cascadeFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Cannot uninstall '%s': %v\n", module.GetName(), err)
+			return 1
+		}
+		fmt.Print(helpers.FormatPlans(plans))
 		return 0
 	}
-	fmt.Fprintf(os.Stderr, "✗ Failed to uninstall '%s'\n", module.GetName())
-	return 1
+
+	for _, m := range order {
+		fmt.Printf("Uninstalling '%s'...\n", m.GetName())
+	}
+	done, err := helpers.UninstallWithDependents(ctx, modules, module, cascadeFlag)
+	for _, m := range done {
+		fmt.Printf("✓ Uninstalled '%s'\n", m.GetName())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %s\n", errorDetail(err, 0))
+		return 1
+	}
+	return 0
+}
+
+// runUpdate updates an installed module to the latest version.
+func runUpdate(ctx context.Context, modules []models.Module, name string) int {
+	module := findModule(modules, name)
+	if module == nil {
+		fmt.Fprintf(os.Stderr, "Error: module '%s' not found\n", name)
+		fmt.Fprintln(os.Stderr, "Use --list to see available modules")
+		return 1
+	}
+
+	switch helpers.GetModuleState(module) {
+	case helpers.StateNotInstalled:
+		fmt.Fprintf(os.Stderr, "Module '%s' is not installed\n", module.GetName())
+		return 1
+	case helpers.StateUpToDate, helpers.StateModified:
+		fmt.Printf("Module '%s' is already up to date (v%d)\n", module.GetName(), module.GetVersion())
+		return 0
+	case helpers.StateOutdated:
+	}
+
+	if dryRunFlag {
+		plans, err := helpers.PlanInstall(ctx, modules, module)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Cannot update '%s': %v\n", module.GetName(), err)
+			return 1
+		}
+		fmt.Print(helpers.FormatPlans(plans))
+		return 0
+	}
+	return updateWithDependencies(ctx, modules, module)
+}
+
+// runApply converges the modules declared in code-template.yml: missing
+// requirements are installed, broken installations repaired and outdated
+// ones updated. A converged repo is left untouched and exits 0, so apply can
+// run unconditionally from bootstrap scripts.
+func runApply(ctx context.Context, modules []models.Module) int {
+	actions, err := helpers.PlanApply(modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot apply %s: %v\n", project.ConfigFile, err)
+		return 1
+	}
+	if len(actions) == 0 {
+		fmt.Printf("Already in sync with %s\n", project.ConfigFile)
+		return 0
+	}
+
+	if dryRunFlag {
+		var plans []helpers.ModulePlan
+		for _, a := range actions {
+			p, err := helpers.PlanApplyAction(ctx, a)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ Cannot %s: %v\n", a, err)
+				return 1
+			}
+			plans = append(plans, p...)
+		}
+		fmt.Print(helpers.FormatPlans(plans))
+		return 0
+	}
+
+	fmt.Printf("Applying %d change(s):\n", len(actions))
+	for _, a := range actions {
+		fmt.Printf("  %s\n", a)
+	}
+	if _, err := helpers.ApplyAll(ctx, modules, actions, printProgress); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Failed to apply %s: %s\n", project.ConfigFile, errorDetail(err, 0))
+		return 1
+	}
+	return 0
+}
+
+// logged wraps a mutating command so the output of the external commands
+// it runs under ctx is written to a log in services.LogDir, whose path is
+// printed when the command fails. Dry runs run no commands and are not
+// logged.
+func logged(ctx context.Context, name string, fn func(ctx context.Context) int) func() int {
+	return func() int {
+		if dryRunFlag {
+			return fn(ctx)
+		}
+		log, err := services.OpenLog(name, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot open log: %v\n", err)
+			return fn(ctx)
+		}
+		defer log.Close()
+
+		code := fn(services.WithLog(ctx, log))
+		if code != 0 && !log.Empty() {
+			fmt.Fprintf(os.Stderr, "Command output written to %s\n", log.Path)
+		}
+		return code
+	}
+}
+
+// runUndo reverts the files changed by the most recent operation that
+// wasn't undone yet. Commands it ran, such as tool installs, stay done.
+func runUndo() int {
+	entry, err := helpers.LastUndoable()
+	if errors.Is(err, helpers.ErrNothingToUndo) {
+		fmt.Println("Nothing to undo")
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot read history: %v\n", err)
+		return 1
+	}
+
+	if dryRunFlag {
+		fmt.Print(formatUndo(entry))
+		return 0
+	}
+	if err := helpers.Undo(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot undo %s: %v\n", entry, err)
+		return 1
+	}
+	fmt.Printf("✓ Undid %s\n", entry)
+	if len(entry.Commands) > 0 {
+		fmt.Println("Commands it ran were not reverted:")
+		for _, command := range entry.Commands {
+			fmt.Printf("  %s\n", command)
+		}
+	}
+	return 0
+}
+
+// runMigrateTools switches the project to go-tools tool mode, moving the
+// tools installed modules built into .bin/ to go.mod tool directives.
+func runMigrateTools(ctx context.Context, modules []models.Module) int {
+	if dryRunFlag {
+		fmt.Print(helpers.FormatGoToolsMigration(ctx, modules))
+		return 0
+	}
+	err := helpers.MigrateGoTools(ctx, modules)
+	if errors.Is(err, helpers.ErrNothingToMigrate) {
+		fmt.Println("Nothing to migrate; tools are already recorded in go.mod")
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Failed to migrate tools: %s\n", errorDetail(err, 0))
+		return 1
+	}
+	fmt.Printf("✓ Tools are recorded in %s and run with go tool\n", services.GoModFile)
+	return 0
+}
+
+// formatUndo describes what undoing entry restores.
+func formatUndo(entry helpers.HistoryEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "undo %s\n", entry)
+	for _, f := range entry.Files {
+		verb := "restore"
+		if f.Change == helpers.FileCreated {
+			verb = "delete"
+		}
+		fmt.Fprintf(&b, "  %-9s %s\n", verb, f.Path)
+	}
+	for _, command := range entry.Commands {
+		fmt.Fprintf(&b, "  %-9s %s\n", "keep", command)
+	}
+	return b.String()
+}
+
+// runHistory lists the operations of the history, oldest first.
+func runHistory() int {
+	entries, err := helpers.ReadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot read history: %v\n", err)
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Println("No operations recorded yet")
+		return 0
+	}
+
+	undone := make(map[int]bool)
+	for _, e := range entries {
+		if e.Action == "undo" && e.Outcome == helpers.HistoryOK {
+			undone[e.Undoes] = true
+		}
+	}
+	for _, e := range entries {
+		line := e.String()
+		switch {
+		case e.Outcome != helpers.HistoryOK:
+			line += " failed: " + e.Error
+		case undone[e.ID]:
+			line += " (undone)"
+		}
+		fmt.Println(line)
+	}
+	return 0
+}
+
+func describeHistory() (any, int) {
+	entries, err := helpers.ReadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot read history: %v\n", err)
+		return nil, 1
+	}
+	return entries, 0
+}
+
+// printProgress prints a line as each module of a batch operation starts
+// and finishes. Independent modules run in parallel, so lines of different
+// modules interleave.
+func printProgress(m models.Module, outcome string, err error) {
+	switch outcome {
+	case helpers.OutcomeRunning:
+		fmt.Printf("→ %s v%d...\n", m.GetName(), m.GetVersion())
+	case helpers.OutcomeFailed:
+		fmt.Fprintf(os.Stderr, "✗ %s failed\n", m.GetName())
+	default:
+		fmt.Printf("✓ %s %s\n", m.GetName(), outcome)
+	}
 }
 
 // runVersion shows version info for a module.
@@ -504,28 +1398,124 @@ func runVersion(modules []models.Module, name string) int {
 	case helpers.StateUpToDate:
 		fmt.Printf("  Status:   installed (up to date)\n")
 	case helpers.StateOutdated:
-		fmt.Printf("  Status:   installed (outdated, v%d → v%d)\n",
-			helpers.GetInstalledVersion(module), module.GetVersion())
+		fmt.Printf("  Status:   installed (outdated, %s)\n", helpers.DescribeOutdated(module))
+	case helpers.StateModified:
+		fmt.Printf("  Status:   installed (managed files modified)\n")
 	case helpers.StateNotInstalled:
 		fmt.Printf("  Status:   not installed\n")
 	}
 	return 0
 }
 
-// runDebugTree prints the tree structure for debugging.
-func runDebugTree(modules []models.Module) int {
-	tree := helpers.BuildTree(modules)
+// describeVersion returns the machine-readable form of runVersion.
+func describeVersion(modules []models.Module, name string) (any, int) {
+	module := findModule(modules, name)
+	if module == nil {
+		fmt.Fprintf(os.Stderr, "Error: module '%s' not found\n", name)
+		return nil, 1
+	}
+	return helpers.DescribeModule(module), 0
+}
+
+// runStatus prints the state of every installed module. With --drift it
+// also prints a diff for each modified managed file and returns 1 if any
+// module has drifted, so it can gate CI.
+func runStatus(modules []models.Module) int {
+	drifted := false
+	for _, m := range modules {
+		switch helpers.GetModuleState(m) {
+		case helpers.StateNotInstalled:
+			continue
+		case helpers.StateUpToDate:
+			fmt.Printf("[✓] %-24s v%d\n", m.GetName(), m.GetVersion())
+		case helpers.StateOutdated:
+			fmt.Printf("[!] %-24s %s\n", m.GetName(), helpers.DescribeOutdated(m))
+		case helpers.StateModified:
+			drifted = true
+			fmt.Printf("[~] %-24s v%d (modified)\n", m.GetName(), m.GetVersion())
+			if !driftFlag {
+				continue
+			}
+			files, err := helpers.DetectDrift(m)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ Cannot check '%s': %v\n", m.GetName(), err)
+				return 1
+			}
+			fmt.Print(helpers.FormatDrift(files))
+		}
+	}
+	if driftFlag && drifted {
+		return 1
+	}
+	return 0
+}
 
+// describeStatus returns the machine-readable form of runStatus: every
+// installed module with the state of its managed files. With --drift the
+// exit code is 1 if any module has drifted.
+func describeStatus(modules []models.Module) (any, int) {
+	infos := helpers.DescribeModules(modules, true)
+	for _, info := range infos {
+		if driftFlag && info.State == helpers.StateModified.String() {
+			return infos, 1
+		}
+	}
+	return infos, 0
+}
+
+// runRestore overwrites a module's modified managed files with the content
+// the module ships.
+func runRestore(modules []models.Module, name string) int {
+	module := findModule(modules, name)
+	if module == nil {
+		fmt.Fprintf(os.Stderr, "Error: module '%s' not found\n", name)
+		fmt.Fprintln(os.Stderr, "Use --list to see available modules")
+		return 1
+	}
+
+	if !module.IsInstalled() {
+		fmt.Fprintf(os.Stderr, "Module '%s' is not installed\n", module.GetName())
+		return 1
+	}
+
+	drifted, err := helpers.DetectDrift(module)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot check '%s': %v\n", module.GetName(), err)
+		return 1
+	}
+	if len(drifted) == 0 {
+		fmt.Printf("Module '%s' has no modified files\n", module.GetName())
+		return 0
+	}
+
+	if dryRunFlag {
+		fmt.Print(helpers.FormatDrift(drifted))
+		return 0
+	}
+
+	if err := helpers.RestoreFiles(drifted); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Failed to restore '%s': %v\n", module.GetName(), err)
+		return 1
+	}
+	for _, f := range drifted {
+		fmt.Printf("✓ Restored %s\n", f.Path)
+	}
+	return 0
+}
+
+// runDebugTree prints the tree structure for debugging.
+func runDebugTree(tree *models.TreeState) int {
 	var printNode func(node *models.TreeNode, indent string)
 	printNode = func(node *models.TreeNode, indent string) {
-		if node.Type == models.NodeCategory {
+		switch node.Type {
+		case models.NodeModule:
+			fmt.Printf("%s[Module] %s (id: %s, path: %s)\n", indent, node.Name, node.ID, node.Module.GetPath())
+		case models.NodePreset:
+			fmt.Printf("%s[Preset] %s (id: %s, modules: %s)\n", indent, node.Name, node.ID, strings.Join(node.Preset.Modules, ", "))
+		case models.NodeMember:
+			fmt.Printf("%s[Member] %s (id: %s)\n", indent, node.Name, node.ID)
+		default:
 			fmt.Printf("%s[Category] %s (id: %s)\n", indent, node.Name, node.ID)
-		} else {
-			path := ""
-			if node.Module != nil {
-				path = node.Module.GetPath()
-			}
-			fmt.Printf("%s[Module] %s (id: %s, path: %s)\n", indent, node.Name, node.ID, path)
 		}
 		for _, child := range node.Children {
 			printNode(child, indent+"  ")
@@ -539,28 +1529,34 @@ func runDebugTree(modules []models.Module) int {
 	return 0
 }
 
-// runList lists all available modules.
-func runList(modules []models.Module) int {
+// runList lists all available modules and presets.
+func runList(modules []models.Module, presets []models.Preset) int {
 	fmt.Println("Available modules:")
 	fmt.Println()
 
-	// Group by category
-	categories := make(map[string][]models.Module)
-	for _, m := range modules {
+	// Group by category, in sorted order
+	var categories []string
+	byCategory := make(map[string][]models.Module)
+	for _, m := range helpers.SortModules(modules) {
 		cat := m.GetCategory()
-		categories[cat] = append(categories[cat], m)
+		if _, ok := byCategory[cat]; !ok {
+			categories = append(categories, cat)
+		}
+		byCategory[cat] = append(byCategory[cat], m)
 	}
 
-	for cat, mods := range categories {
+	for _, cat := range categories {
 		fmt.Printf("  %s:\n", cat)
-		for _, m := range mods {
+		for _, m := range byCategory[cat] {
 			state := helpers.GetModuleState(m)
 			var status string
 			switch state {
 			case helpers.StateUpToDate:
 				status = fmt.Sprintf("[✓] v%d", m.GetVersion())
 			case helpers.StateOutdated:
-				status = fmt.Sprintf("[!] v%d → v%d", helpers.GetInstalledVersion(m), m.GetVersion())
+				status = "[!] " + helpers.DescribeOutdated(m)
+			case helpers.StateModified:
+				status = fmt.Sprintf("[~] v%d (modified)", m.GetVersion())
 			case helpers.StateNotInstalled:
 				status = "[ ]"
 			}
@@ -568,21 +1564,167 @@ func runList(modules []models.Module) int {
 		}
 		fmt.Println()
 	}
+
+	if len(presets) > 0 {
+		fmt.Println("  presets:")
+		for _, p := range presets {
+			status := "[ ]"
+			if helpers.IsPresetInstalled(modules, p) {
+				status = "[✓]"
+			}
+			fmt.Printf("    %-20s %s %s\n", p.Name, status, strings.Join(p.Modules, ", "))
+		}
+		fmt.Println()
+	}
+	return 0
+}
+
+// runDoctor prints a table of what code-template and its modules need on
+// the machine, with detected versions and hints for anything missing.
+// Returns 1 when an installed module is missing a requirement.
+func runDoctor(modules []models.Module) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tREQUIREMENT\tSTATUS\tVERSION\tHINT")
+
+	failed := 0
+	for _, c := range helpers.Diagnose(modules) {
+		status, version, hint := "found", c.Version, ""
+		switch {
+		case c.Failed():
+			status, hint = "missing (in use)", c.Hint
+			failed++
+		case !c.Found:
+			status, hint = "missing", c.Hint
+		}
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Module, c.Requirement, status, version, hint)
+	}
+	w.Flush()
+
+	fmt.Println()
+	if failed > 0 {
+		fmt.Printf("✗ %d requirement(s) of installed modules missing\n", failed)
+		return 1
+	}
+	fmt.Println("✓ Everything installed modules need is present")
+	return 0
+}
+
+// describeDoctor returns the machine-readable form of runDoctor.
+func describeDoctor(modules []models.Module) (any, int) {
+	checks := helpers.Diagnose(modules)
+	for _, c := range checks {
+		if c.Failed() {
+			return checks, 1
+		}
+	}
+	return checks, 0
+}
+
+// runCache lists, prunes or verifies the shared cache of tool builds. list
+// and verify follow --output; verify returns 1 if any build is corrupt.
+func runCache(subcommand string) int {
+	cache := services.Go.Cache
+	if cache == nil {
+		fmt.Fprintln(os.Stderr, "Error: no user cache directory; set XDG_CACHE_HOME or HOME")
+		return 1
+	}
+	entries, err := cache.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Cannot read %s: %v\n", cache.Dir, err)
+		return 1
+	}
+
+	switch subcommand {
+	case "prune":
+		removed, err := cache.Prune(olderThanFlag)
+		for _, dir := range removed {
+			fmt.Printf("Removed %s\n", dir)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Failed to prune %s: %v\n", cache.Dir, err)
+			return 1
+		}
+		fmt.Printf("✓ Removed %d cached build(s)\n", len(removed))
+		return 0
+
+	case "verify":
+		type result struct {
+			Build string `json:"build" yaml:"build"`
+			Error string `json:"error,omitempty" yaml:"error,omitempty"`
+		}
+		results := []result{}
+		failed := 0
+		for _, e := range entries {
+			r := result{Build: e.String()}
+			if err := cache.Verify(e); err != nil {
+				r.Error = err.Error()
+				failed++
+			}
+			results = append(results, r)
+		}
+		code := 0
+		if failed > 0 {
+			code = 1
+		}
+		if outputFlag != "text" {
+			if err := writeOutput(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return code
+		}
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Printf("✗ %s: %s\n", r.Build, r.Error)
+			} else {
+				fmt.Printf("✓ %s\n", r.Build)
+			}
+		}
+		if failed > 0 {
+			fmt.Printf("\n%d corrupt build(s); cache prune removes them\n", failed)
+		}
+		return code
+	}
+
+	if outputFlag != "text" {
+		if err := writeOutput(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No tools cached in %s\n", cache.Dir)
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tVERSION\tSIZE\tLAST USED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%.1f MB\t%s\n", e.Package, e.Version, float64(e.Size)/(1<<20), e.Used.Local().Format("2006-01-02 15:04"))
+	}
+	w.Flush()
 	return 0
 }
 
-// runTUI runs the interactive terminal UI.
-func runTUI(modules []models.Module) int {
-	tree := helpers.BuildTree(modules)
+// runTUI runs the interactive terminal UI. In a workspace the tree has a
+// top level per member.
+func runTUI(modules []models.Module, presets []models.Preset, members []string) int {
+	tree := buildTree(modules, presets, members)
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(primaryColor)
 
 	m := ViewModel{
+		Modules:     modules,
 		Tree:        tree,
 		SelectedIdx: 0,
+		logView:     viewport.New(logWidth, logHeight),
 		spinner:     s,
+		states:      readStates(tree),
 	}
 
 	p := tea.NewProgram(m)
@@ -593,33 +1735,379 @@ func runTUI(modules []models.Module) int {
 	return 0
 }
 
+// promptDefaults returns the answers initialization gives without prompting:
+// those code-template.yml configures, overridden by --adopt and
+// --install-task.
+func promptDefaults() (map[string]bool, error) {
+	defaults, err := autoinit.LoadDefaults()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []struct{ name, prompt, value string }{
+		{"adopt", autoinit.PromptAdopt, adoptFlag},
+		{"install-task", autoinit.PromptInstallTask, taskFlag},
+	} {
+		switch f.value {
+		case "":
+		case "yes":
+			defaults[f.prompt] = true
+		case "no":
+			defaults[f.prompt] = false
+		default:
+			return nil, fmt.Errorf("--%s must be 'yes' or 'no'", f.name)
+		}
+	}
+	return defaults, nil
+}
+
+// buildTree builds the tree the TUI shows: modules by category and the
+// presets, or one node per workspace member holding its own.
+func buildTree(modules []models.Module, presets []models.Preset, members []string) *models.TreeState {
+	if len(members) == 1 && members[0] == "" {
+		tree := helpers.BuildTree(modules)
+		helpers.AddPresets(tree, modules, presets)
+		return tree
+	}
+	return helpers.BuildWorkspaceTree(modules, presets, members)
+}
+
+// setProjectRoot resolves the project root from --root, or by walking up
+// from the working directory, so it is the only thing that depends on it.
+// Inside a workspace the root is the workspace root, and the member the
+// directory lies in is returned.
+func setProjectRoot(dir string) (string, error) {
+	start := dir
+	if start == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		start = wd
+	}
+
+	root, member, ok, err := workspace.Locate(start)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return member, project.SetRoot(root)
+	}
+
+	if dir == "" {
+		if dir, err = project.Find(start); err != nil {
+			return "", err
+		}
+	}
+	return "", project.SetRoot(dir)
+}
+
+// selectMembers returns the workspace members a run operates on: every
+// member with --all, the one named by --member, or else the one the working
+// directory lies in. At the workspace root itself, commands that change
+// anything need --member or --all while the rest cover every member.
+// Outside a workspace the project root is the only "member", "".
+func selectMembers(current, command string) ([]string, error) {
+	members, err := workspace.Members()
+	if err != nil {
+		return nil, err
+	}
+	if members == nil {
+		if allFlag || memberFlag != "" {
+			return nil, errors.New("--member and --all need a workspace defined in " + project.ConfigFile)
+		}
+		return []string{""}, nil
+	}
+
+	switch {
+	case allFlag:
+		return members, nil
+	case memberFlag != "":
+		member, err := workspace.Lookup(members, memberFlag)
+		if err != nil {
+			return nil, err
+		}
+		return []string{member}, nil
+	case current != "":
+		return []string{current}, nil
+	case installFlag != "" || uninstallFlag != "" || updateFlag != "" || restoreFlag != "" || command == "apply" || command == "undo" || command == "migrate-tools":
+		return nil, errors.New("this is a workspace root; choose members with --member <dir> or --all")
+	}
+	return members, nil
+}
+
+// eachMember runs fn in every member and returns the highest exit code.
+// Output is headed by the member's name when there are several.
+func eachMember(members []string, fn func() int) int {
+	code := 0
+	for i, member := range members {
+		project.UseMember(member)
+		if len(members) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("== %s ==\n", member)
+		}
+		if c := fn(); c > code {
+			code = c
+		}
+	}
+	return code
+}
+
+// report runs a read command in every member: text prints its
+// human-readable output under member headers, while --output json|yaml
+// prints what describe returns as a single document.
+func report(members []string, text func() int, describe func() (any, int)) int {
+	if outputFlag == "text" {
+		return eachMember(members, text)
+	}
+	return outputEachMember(members, describe)
+}
+
+// memberOutput is one member's result in a workspace-wide document.
+type memberOutput struct {
+	Member string `json:"member" yaml:"member"`
+	Result any    `json:"result" yaml:"result"`
+}
+
+// outputEachMember runs describe in every member and writes the results as
+// one document: the result itself outside a workspace or for a single
+// member, otherwise a list of results by member. A nil result, for a
+// command that failed with a message on stderr, is left out. Returns the
+// highest exit code.
+func outputEachMember(members []string, describe func() (any, int)) int {
+	code := 0
+	var results []memberOutput
+	for _, member := range members {
+		project.UseMember(member)
+		result, c := describe()
+		if c > code {
+			code = c
+		}
+		if result != nil {
+			results = append(results, memberOutput{Member: member, Result: result})
+		}
+	}
+
+	var doc any = results
+	switch {
+	case len(members) == 1 && len(results) == 0:
+		return code
+	case len(members) == 1:
+		doc = results[0].Result
+	case results == nil:
+		doc = []memberOutput{}
+	}
+	if err := writeOutput(doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return code
+}
+
+// writeOutput prints v to stdout in the --output format.
+func writeOutput(v any) error {
+	var data []byte
+	var err error
+	if outputFlag == "yaml" {
+		data, err = yaml.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// inMember returns " in <member>" for messages about a workspace member,
+// and "" outside a workspace.
+func inMember(member string) string {
+	if member == "" {
+		return ""
+	}
+	return " in " + member
+}
+
 func main() {
-	flag.Parse()
+	command, subcommand, err := parseArgs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// The cache is the user's, not the project's
+	if command == "cache" {
+		os.Exit(runCache(subcommand))
+	}
 
-	if err := autoinit.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Initialization failed: %v\n", err)
+	current, err := setProjectRoot(rootFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid project root: %v\n", err)
 		os.Exit(1)
 	}
 
-	modules := helpers.GetModules()
+	if command == "sync" {
+		command = "apply"
+	}
+	members, err := selectMembers(current, command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid workspace: %v\n", err)
+		os.Exit(1)
+	}
+
+	modules, skipped, err := helpers.LoadModules()
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %v\n", err)
+	}
+	if err == nil {
+		err = helpers.ValidateDependencies(modules)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid module definitions: %v\n", err)
+		os.Exit(1)
+	}
+
+	presets, err := helpers.LoadPresets()
+	if err == nil {
+		err = helpers.ValidatePresets(modules, presets)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid presets: %v\n", err)
+		os.Exit(1)
+	}
+
+	// doctor diagnoses what first-run setup would fail on, so it runs before it
+	if command == "doctor" {
+		os.Exit(report(members,
+			func() int { return runDoctor(modules) },
+			func() (any, int) { return describeDoctor(modules) }))
+	}
+
+	// ctrl+c cancels the running operation, which rolls back; a second one
+	// kills the process as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if recoverFlag != "rollback" && recoverFlag != "resume" {
+		fmt.Fprintf(os.Stderr, "Error: --recover must be 'rollback' or 'resume'\n")
+		os.Exit(1)
+	}
+	defaults, err := promptDefaults()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	initOptions := autoinit.Options{
+		NoInput:   noInputFlag || autoinit.NonInteractiveFromEnv(),
+		AssumeYes: yesFlag,
+		Defaults:  defaults,
+	}
+	// Keep stdout to the document when it is machine-readable
+	messages := os.Stdout
+	if outputFlag != "text" {
+		messages = os.Stderr
+		initOptions.Output = messages
+	}
+	initialize, planInit := autoinit.Run, autoinit.PlanRun
+	if command == "init" {
+		initialize, planInit = autoinit.Init, autoinit.PlanInit
+	}
+	for _, member := range members {
+		project.UseMember(member)
+		if dryRunFlag {
+			// A dry run changes nothing, so initialization and recovery
+			// are only reported
+			recovery, err := helpers.PlanRecovery(recoverFlag == "resume")
+			for _, line := range append(planInit(modules), recovery...) {
+				fmt.Fprintln(messages, line+inMember(member))
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Recovery failed%s: %s\n", inMember(member), errorDetail(err, 0))
+				os.Exit(1)
+			}
+			continue
+		}
+		if err := initialize(ctx, modules, initOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "Initialization failed%s: %v\n", inMember(member), err)
+			os.Exit(1)
+		}
+
+		recovered, err := helpers.RecoverTransactions(ctx, modules, recoverFlag == "resume")
+		for _, line := range recovered {
+			fmt.Fprintln(messages, line+inMember(member))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Recovery failed%s: %s\n", inMember(member), errorDetail(err, 0))
+			os.Exit(1)
+		}
+	}
 
 	// Handle CLI commands
+	if command == "apply" {
+		os.Exit(eachMember(members, logged(ctx, "apply", func(ctx context.Context) int { return runApply(ctx, modules) })))
+	}
+	if command == "undo" {
+		os.Exit(eachMember(members, runUndo))
+	}
+	if command == "history" {
+		os.Exit(report(members, runHistory, describeHistory))
+	}
+	if command == "migrate-tools" {
+		os.Exit(eachMember(members, logged(ctx, "migrate-tools", func(ctx context.Context) int { return runMigrateTools(ctx, modules) })))
+	}
 	if installFlag != "" {
-		os.Exit(runInstall(modules, installFlag))
+		os.Exit(eachMember(members, logged(ctx, installFlag, func(ctx context.Context) int { return runInstall(ctx, modules, presets, installFlag) })))
 	}
 	if uninstallFlag != "" {
-		os.Exit(runUninstall(modules, uninstallFlag))
+		os.Exit(eachMember(members, logged(ctx, uninstallFlag, func(ctx context.Context) int { return runUninstall(ctx, modules, uninstallFlag) })))
+	}
+	if updateFlag != "" {
+		os.Exit(eachMember(members, logged(ctx, updateFlag, func(ctx context.Context) int { return runUpdate(ctx, modules, updateFlag) })))
+	}
+	if restoreFlag != "" {
+		os.Exit(eachMember(members, logged(ctx, restoreFlag, func(context.Context) int { return runRestore(modules, restoreFlag) })))
+	}
+	if statusFlag {
+		os.Exit(report(members,
+			func() int { return runStatus(modules) },
+			func() (any, int) { return describeStatus(modules) }))
 	}
 	if versionFlag != "" {
-		os.Exit(runVersion(modules, versionFlag))
+		os.Exit(report(members,
+			func() int { return runVersion(modules, versionFlag) },
+			func() (any, int) { return describeVersion(modules, versionFlag) }))
 	}
 	if listFlag {
-		os.Exit(runList(modules))
+		os.Exit(report(members,
+			func() int { return runList(modules, presets) },
+			func() (any, int) { return helpers.DescribeList(modules, presets), 0 }))
 	}
 	if debugTreeFlag {
-		os.Exit(runDebugTree(modules))
+		// The tree covers every member, as the TUI shows it
+		tree := buildTree(modules, presets, members)
+		if outputFlag != "text" {
+			os.Exit(outputEachMember([]string{project.Member()}, func() (any, int) {
+				return helpers.DescribeTree(tree.Roots), 0
+			}))
+		}
+		os.Exit(runDebugTree(tree))
+	}
+
+	if command == "init" {
+		os.Exit(0)
 	}
 
 	// No CLI flags, run TUI
-	os.Exit(runTUI(modules))
+	if !initOptions.Interactive() {
+		fmt.Fprintf(os.Stderr, "Error: the interactive UI needs input; pass a command or flag when running non-interactively\n")
+		os.Exit(1)
+	}
+	os.Exit(runTUI(modules, presets, members))
 }
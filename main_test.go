@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"code-template/helpers"
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
+	"code-template/models"
+)
+
+// confirm answers the pending confirmation of m with y and runs the
+// operation it starts to the end, delivering its events on the way and
+// rendering after each, as the TUI does.
+func confirm(t *testing.T, m ViewModel) ViewModel {
+	t.Helper()
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	batch := cmd().(tea.BatchMsg)
+	result := make(chan tea.Msg)
+	go func() { result <- batch[0]() }()
+	for msg := batch[1](); msg != nil; msg = waitForEvent(model.(ViewModel).events)() {
+		model, _ = model.Update(msg)
+		model.View()
+	}
+	model, _ = model.Update(<-result)
+	return model.(ViewModel)
+}
+
+func TestViewModel_RunsOperationsBackToBack(t *testing.T) {
+	dir := t.TempDir()
+	if err := project.SetRoot(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	m := ViewModel{Tree: models.NewTreeState(), logView: viewport.New(logWidth, logHeight), spinner: spinner.New()}
+	for i := 1; i <= 2; i++ {
+		m.Confirm = &confirmation{
+			name: "op",
+			run: func(ctx context.Context, _ helpers.ProgressFunc) tea.Msg {
+				return installResultMsg{moduleName: "op", action: "install", err: ctx.Err()}
+			},
+		}
+		m = confirm(t, m)
+		if m.StatusIsError {
+			t.Fatalf("expected operation %d to succeed, got %q", i, m.StatusMessage)
+		}
+	}
+}
+
+// pinnedModule is an installed module that counts how often its tools are
+// asked for their versions.
+type pinnedModule struct {
+	probes int
+}
+
+func (m *pinnedModule) GetName() string                              { return "pinned" }
+func (m *pinnedModule) GetCategory() string                          { return "test" }
+func (m *pinnedModule) GetPath() string                              { return "test/pinned" }
+func (m *pinnedModule) GetVersion() int                              { return 1 }
+func (m *pinnedModule) GetKey() string                               { return "pinned" }
+func (m *pinnedModule) GetRequires() []string                        { return nil }
+func (m *pinnedModule) GetConflicts() []string                       { return nil }
+func (m *pinnedModule) IsInstalled() bool                            { return true }
+func (m *pinnedModule) Install(context.Context) error                { return nil }
+func (m *pinnedModule) Uninstall(context.Context) error              { return nil }
+func (m *pinnedModule) ToolSteps(context.Context) []transaction.Step { return nil }
+func (m *pinnedModule) OutdatedTools() []string                      { m.probes++; return []string{"tool 1.0, want v2"} }
+
+func TestViewModel_ReadsToolVersionsOnlyAfterOperations(t *testing.T) {
+	if err := project.SetRoot(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	module := &pinnedModule{}
+	node := &models.TreeNode{ID: "pinned", Name: "pinned", Type: models.NodeModule, Module: module}
+	tree := models.NewTreeState()
+	tree.Roots = append(tree.Roots, node)
+	tree.RebuildFlatVisible()
+
+	m := ViewModel{Tree: tree, logView: viewport.New(logWidth, logHeight), spinner: spinner.New()}
+	m.states = readStates(tree)
+	probes := module.probes
+	for range 10 {
+		if !strings.Contains(m.View(), "tool 1.0, want v2") {
+			t.Fatal("expected the outdated tool to be shown")
+		}
+		model, _ := m.Update(m.spinner.Tick())
+		m = model.(ViewModel)
+	}
+	if module.probes != probes {
+		t.Errorf("expected no tool probes while idle, got %d", module.probes-probes)
+	}
+}
+
+func TestViewModel_RendersWhileAnOperationRunsInAMember(t *testing.T) {
+	dir := t.TempDir()
+	if err := project.SetRoot(dir); err != nil {
+		t.Fatal(err)
+	}
+	member := filepath.Join(dir, "apps", "api")
+
+	// The tree shows a module of the workspace root while the operation
+	// runs in the member
+	node := &models.TreeNode{ID: "pinned", Name: "pinned", Type: models.NodeModule, Module: &pinnedModule{}}
+	tree := models.NewTreeState()
+	tree.Roots = append(tree.Roots, node)
+	tree.RebuildFlatVisible()
+	m := ViewModel{Tree: tree, logView: viewport.New(logWidth, logHeight), spinner: spinner.New()}
+	m.states = readStates(tree)
+
+	var roots []string
+	m.Confirm = &confirmation{
+		name:   "op",
+		member: "apps/api",
+		run: func(_ context.Context, progress helpers.ProgressFunc) tea.Msg {
+			for range 20 {
+				roots = append(roots, project.Root())
+				progress(node.Module, "installed", nil)
+			}
+			return installResultMsg{moduleName: "op", action: "install"}
+		},
+	}
+	m = confirm(t, m)
+
+	for i, root := range roots {
+		if root != member {
+			t.Fatalf("expected the operation to run in %s, got %s at step %d", member, root, i+1)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "fmt"
+
+// StepError reports which step of an install or uninstall failed and why.
+type StepError struct {
+	Step int    // 1-based step number
+	Name string // Short description, e.g. "copy golangci.yml"
+	Err  error  // Underlying cause
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("Step %d: %s: %v", e.Step, e.Name, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// MissingRequirementError is returned when something a module needs on the
+// machine isn't there.
+type MissingRequirementError struct {
+	Name string // e.g. "npm"
+	Hint string // How to install it
+}
+
+func (e *MissingRequirementError) Error() string {
+	if e.Hint == "" {
+		return e.Name + " not found"
+	}
+	return fmt.Sprintf("%s not found (%s)", e.Name, e.Hint)
+}
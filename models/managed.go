@@ -0,0 +1,35 @@
+package models
+
+// ManagedFile is a file a module writes verbatim from embedded content.
+type ManagedFile struct {
+	Path    string // Destination relative to the project root
+	Content []byte // Canonical embedded content
+}
+
+// FileManager is implemented by modules that own files with canonical
+// content, so local edits to those files can be detected and reverted.
+type FileManager interface {
+	ManagedFiles() []ManagedFile
+}
+
+// VersionedFileManager is implemented by file managers that keep the content
+// shipped by earlier versions, so updates can merge it with local edits.
+// When a module bumps its Version it should embed the old content alongside
+// the new and keep returning it for the old version.
+type VersionedFileManager interface {
+	FileManager
+	ManagedFilesAt(version int) []ManagedFile // nil for unknown versions
+}
+
+// NpmPackage is an npm package a module installs, globally when Dir is empty.
+type NpmPackage struct {
+	Name string
+	Dir  string
+}
+
+// ToolInstaller is implemented by modules that install Go binaries into
+// .bin/ or npm packages, so the versions they resolved to can be locked.
+type ToolInstaller interface {
+	Binaries() []string // Names of binaries installed into .bin/
+	NpmPackages() []NpmPackage
+}
@@ -1,12 +1,23 @@
 package models
 
+import "context"
+
 type Module interface {
 	GetName() string
 	GetCategory() string
 	GetPath() string // Hierarchical path, e.g., "linting/golangci_lint"
 	GetVersion() int
 	GetKey() string
+	GetRequires() []string  // Keys of modules that must be installed first
+	GetConflicts() []string // Keys of modules that cannot be installed alongside
 	IsInstalled() bool
-	Install() bool
-	Uninstall() bool
+	Install(ctx context.Context) error   // Returns a *StepError describing the failed step
+	Uninstall(ctx context.Context) error // Best-effort; joins the errors of every failed step
+}
+
+// Detector is implemented by modules that can recognise an installation
+// code-template didn't make, such as one set up by hand, so init can adopt
+// it into code-template.yml.
+type Detector interface {
+	Detect() bool
 }
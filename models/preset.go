@@ -0,0 +1,8 @@
+package models
+
+// Preset is a named set of modules installed together as one operation.
+type Preset struct {
+	Name        string
+	Description string
+	Modules     []string // Module names or keys
+}
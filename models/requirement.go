@@ -0,0 +1,16 @@
+package models
+
+// Requirement is a tool or system library a module needs on the machine.
+type Requirement struct {
+	Name    string        // What is needed, e.g. "npm" or "gtk+-3.0"
+	Hint    string        // How to install it when missing
+	Found   func() bool   // Whether it is present
+	Version func() string // Detected version, "" when unknown; may be nil
+}
+
+// RequirementProvider is implemented by modules that declare what they need
+// on the machine, so installs can fail with a hint and doctor can check it
+// up front.
+type RequirementProvider interface {
+	Requirements() []Requirement
+}
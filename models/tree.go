@@ -6,6 +6,8 @@ type NodeType int
 const (
 	NodeCategory NodeType = iota // Expandable folder
 	NodeModule                   // Leaf node (installable)
+	NodePreset                   // Installable set of modules, expandable to its members
+	NodeMember                   // Workspace member, expandable to its own module tree
 )
 
 // TreeNode represents a single node in the tree hierarchy.
@@ -17,7 +19,9 @@ type TreeNode struct {
 	Expanded bool        // Whether children are visible (categories only)
 	Children []*TreeNode // Child nodes
 	Module   Module      // Non-nil for module nodes
+	Preset   *Preset     // Non-nil for preset nodes
 	Parent   *TreeNode   // Parent node (nil for root)
+	Member   string      // Workspace member the node belongs to ("" outside a workspace)
 }
 
 // TreeState holds the complete tree state for the TUI.
@@ -61,14 +65,22 @@ func (t *TreeState) RebuildFlatVisible() {
 
 func (t *TreeState) flattenNode(node *TreeNode) {
 	t.FlatVisible = append(t.FlatVisible, node)
-	if node.Type == NodeCategory && t.IsExpanded(node.ID) {
+	if node.IsExpandable() && t.IsExpanded(node.ID) {
 		for _, child := range node.Children {
 			t.flattenNode(child)
 		}
 	}
 }
 
-// GetInstalledCount returns (installed, total) module counts for a category node.
+// IsExpandable returns true for nodes with children (categories, presets and
+// workspace members).
+func (node *TreeNode) IsExpandable() bool {
+	return node.Type == NodeCategory || node.Type == NodePreset || node.Type == NodeMember
+}
+
+// GetInstalledCount returns (installed, total) module counts for a category,
+// preset or member node. A member skips its presets, whose modules already
+// appear under its categories.
 func (node *TreeNode) GetInstalledCount() (int, int) {
 	if node.Type == NodeModule {
 		if node.Module != nil && node.Module.IsInstalled() {
@@ -79,6 +91,9 @@ func (node *TreeNode) GetInstalledCount() (int, int) {
 
 	installed, total := 0, 0
 	for _, child := range node.Children {
+		if node.Type == NodeMember && child.holdsPresets() {
+			continue
+		}
 		ci, ct := child.GetInstalledCount()
 		installed += ci
 		total += ct
@@ -86,6 +101,11 @@ func (node *TreeNode) GetInstalledCount() (int, int) {
 	return installed, total
 }
 
+// holdsPresets returns true for the category that holds the presets.
+func (node *TreeNode) holdsPresets() bool {
+	return len(node.Children) > 0 && node.Children[0].Type == NodePreset
+}
+
 // HasModules returns true if the node contains any modules (directly or in descendants).
 func (node *TreeNode) HasModules() bool {
 	if node.Type == NodeModule {
@@ -1,10 +1,17 @@
 package getshitdone
 
 import (
+	"context"
 	"os"
 	"os/exec"
+	"strings"
 
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
 	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
 )
 
 const (
@@ -22,10 +29,12 @@ var Module = &GetShitDoneModule{
 }
 
 type GetShitDoneModule struct {
-	Name     string
-	Version  int
-	Category string
-	Path     string
+	Name      string
+	Version   int
+	Category  string
+	Path      string
+	Requires  []string
+	Conflicts []string
 }
 
 func (m *GetShitDoneModule) GetName() string {
@@ -48,27 +57,38 @@ func (m *GetShitDoneModule) GetKey() string {
 	return moduleKey
 }
 
-// isNpxAvailable checks if npx is available in PATH
-func isNpxAvailable() bool {
-	_, err := exec.LookPath("npx")
-	return err == nil
+func (m *GetShitDoneModule) GetRequires() []string {
+	return m.Requires
+}
+
+func (m *GetShitDoneModule) GetConflicts() []string {
+	return m.Conflicts
+}
+
+// Requirements returns what gsd needs on the machine: npx, to run its
+// installer.
+func (m *GetShitDoneModule) Requirements() []models.Requirement {
+	return []models.Requirement{services.NpxRequirement}
 }
 
 // isGsdInstalled checks if gsd is installed locally by checking for VERSION file
 func isGsdInstalled() bool {
-	_, err := os.Stat(gsdVersionFile)
+	_, err := os.Stat(project.Path(gsdVersionFile))
 	return err == nil
 }
 
+var gsdInstallArgs = []string{"npx", gsdPackage, "--local"}
+
 // installGsd runs npx get-shit-done-cc --local to install gsd locally
-func installGsd() error {
-	cmd := exec.Command("npx", gsdPackage, "--local")
-	return cmd.Run()
+func installGsd(ctx context.Context) error {
+	cmd := exec.Command(gsdInstallArgs[0], gsdInstallArgs[1:]...)
+	return services.Run(ctx, cmd)
 }
 
 // IsInstalled checks:
 // 1. gsd command exists in PATH
 // 2. Entry exists in code-template.yml
+// 3. The files and binaries code-template.lock records still exist
 func (m *GetShitDoneModule) IsInstalled() bool {
 	// Check 1: gsd binary in PATH
 	if !isGsdInstalled() {
@@ -81,40 +101,45 @@ func (m *GetShitDoneModule) IsInstalled() bool {
 		return false
 	}
 
-	return true
+	// Check 3: What code-template.lock records is still there
+	return lockfile.Intact(moduleKey)
 }
 
-// Install runs npx get-shit-done-cc to install gsd
-func (m *GetShitDoneModule) Install() bool {
-	// Step 1: Check npx is available
-	if !isNpxAvailable() {
-		return false
-	}
+// Detect reports whether gsd is installed in the project, which its
+// .claude/get-shit-done/VERSION file marks.
+func (m *GetShitDoneModule) Detect() bool {
+	return isGsdInstalled()
+}
 
-	// Step 2: Install gsd via npx (if not already present)
-	if !isGsdInstalled() {
-		if err := installGsd(); err != nil {
-			return false
+// Steps returns the transaction steps for installing or uninstalling gsd.
+func (m *GetShitDoneModule) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		return []transaction.Step{
+			transaction.CheckRequirement(services.NpxRequirement),
+			{
+				Name:    "install gsd via npx",
+				Skip:    isGsdInstalled,
+				Do:      func() error { return installGsd(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand(strings.Join(gsdInstallArgs, " "), "")},
+			},
+			transaction.SetKey(codeTemplateFileName, moduleKey, m.Version),
+		}
+	case transaction.ActionUninstall:
+		// Note: We do NOT uninstall gsd as it may be used elsewhere
+		return []transaction.Step{
+			transaction.RemoveKey(codeTemplateFileName, moduleKey),
 		}
 	}
+	return nil
+}
 
-	// Step 3: Add entry to code-template.yml
-	if err := yamlhelper.SetKey(codeTemplateFileName, moduleKey, m.Version); err != nil {
-		return false
-	}
-
-	return true
+// Install runs npx get-shit-done-cc to install gsd
+func (m *GetShitDoneModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, moduleKey, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
 }
 
 // Uninstall removes the code-template.yml entry (does NOT uninstall gsd)
-func (m *GetShitDoneModule) Uninstall() bool {
-	success := true
-
-	// Remove entry from code-template.yml
-	// Note: We do NOT uninstall gsd as it may be used elsewhere
-	if err := yamlhelper.RemoveKey(codeTemplateFileName, moduleKey); err != nil {
-		success = false
-	}
-
-	return success
+func (m *GetShitDoneModule) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(moduleKey, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
 }
@@ -1,7 +1,12 @@
 package tddguard
 
 import (
+	"code-template/helpers/lockfile"
+	"code-template/helpers/transaction"
 	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
+	"context"
 )
 
 const (
@@ -17,10 +22,12 @@ var Module = &TddGuardModule{
 }
 
 type TddGuardModule struct {
-	Name     string
-	Version  int
-	Category string
-	Path     string
+	Name      string
+	Version   int
+	Category  string
+	Path      string
+	Requires  []string
+	Conflicts []string
 }
 
 func (m *TddGuardModule) GetName() string {
@@ -43,10 +50,34 @@ func (m *TddGuardModule) GetKey() string {
 	return moduleKey
 }
 
+func (m *TddGuardModule) GetRequires() []string {
+	return m.Requires
+}
+
+func (m *TddGuardModule) GetConflicts() []string {
+	return m.Conflicts
+}
+
+// Requirements returns what tdd-guard needs on the machine: npm.
+func (m *TddGuardModule) Requirements() []models.Requirement {
+	return []models.Requirement{services.NpmRequirement}
+}
+
+// Binaries returns nil; tdd-guard comes from npm.
+func (m *TddGuardModule) Binaries() []string {
+	return nil
+}
+
+// NpmPackages returns the global npm package tdd-guard installs.
+func (m *TddGuardModule) NpmPackages() []models.NpmPackage {
+	return []models.NpmPackage{{Name: tddGuardPackage}}
+}
+
 // IsInstalled checks:
 // 1. tdd-guard command exists in PATH
 // 2. Hooks are configured in .claude/settings.json
 // 3. Entry exists in code-template.yml
+// 4. The files and binaries code-template.lock records still exist
 func (m *TddGuardModule) IsInstalled() bool {
 	// Check 1: tdd-guard binary in PATH
 	if !IsTddGuardInstalled() {
@@ -64,53 +95,70 @@ func (m *TddGuardModule) IsInstalled() bool {
 		return false
 	}
 
-	return true
+	// Check 4: What code-template.lock records is still there
+	return lockfile.Intact(moduleKey)
 }
 
-// Install performs installation with rollback on failure
-func (m *TddGuardModule) Install() bool {
-	// Step 1: Check npm is available
-	if !CheckNpmInstalled() {
-		return false
-	}
+// Detect reports whether the tdd-guard hooks are configured in
+// .claude/settings.json.
+func (m *TddGuardModule) Detect() bool {
+	return AreHooksConfigured()
+}
 
-	// Step 2: Install tdd-guard via npm (if not already present)
-	if !IsTddGuardInstalled() {
-		if err := InstallTddGuard(); err != nil {
-			return false
+// Steps returns the transaction steps for installing or uninstalling tdd-guard.
+// The npm package itself is never removed (user might use it elsewhere).
+func (m *TddGuardModule) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		return []transaction.Step{
+			transaction.CheckRequirement(services.NpmRequirement),
+			{
+				Name:    "install tdd-guard via npm",
+				Skip:    hasPinnedTddGuard,
+				Do:      func() error { return InstallTddGuard(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand(npmService.InstallCommand(pinnedPackage()), "")},
+			},
+			{
+				Name:    "configure hooks in .claude/settings.json",
+				Do:      AddHooks,
+				Undo:    RemoveHooks,
+				Effects: []transaction.Effect{transaction.EditsFile(getSettingsPath(), "add tdd-guard hooks")},
+			},
+			transaction.SetKey(codeTemplateFileName, moduleKey, m.Version),
+		}
+	case transaction.ActionUninstall:
+		return []transaction.Step{
+			{
+				Name:    "remove hooks from settings.json",
+				Do:      RemoveHooks,
+				Effects: []transaction.Effect{transaction.EditsFile(getSettingsPath(), "remove tdd-guard hooks")},
+			},
+			transaction.RemoveKey(codeTemplateFileName, moduleKey),
 		}
 	}
+	return nil
+}
 
-	// Step 3: Configure hooks in .claude/settings.json
-	if err := AddHooks(); err != nil {
-		// Note: We do NOT uninstall npm package per requirements
-		return false
-	}
-
-	// Step 4: Add entry to code-template.yml
-	if err := yamlhelper.SetKey(codeTemplateFileName, moduleKey, m.Version); err != nil {
-		RemoveHooks() // Best-effort rollback
-		return false
-	}
-
-	return true
+// Install performs installation with rollback on failure
+func (m *TddGuardModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, moduleKey, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
 }
 
 // Uninstall removes configuration (but NOT the npm package)
-func (m *TddGuardModule) Uninstall() bool {
-	success := true
-
-	// Step 1: Remove hooks from settings.json
-	if err := RemoveHooks(); err != nil {
-		success = false
-	}
+func (m *TddGuardModule) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(moduleKey, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
+}
 
-	// Step 2: Remove entry from code-template.yml
-	if err := yamlhelper.RemoveKey(codeTemplateFileName, moduleKey); err != nil {
-		success = false
+// OutdatedTools reports the tdd-guard in PATH if it isn't the pinned
+// version.
+func (m *TddGuardModule) OutdatedTools() []string {
+	if mismatch := toolMismatch(); mismatch != "" {
+		return []string{mismatch}
 	}
+	return nil
+}
 
-	// Note: Do NOT uninstall npm package (user might use elsewhere)
-
-	return success
+// ToolSteps returns the step that installs the pinned tdd-guard.
+func (m *TddGuardModule) ToolSteps(ctx context.Context) []transaction.Step {
+	return []transaction.Step{reinstallStep(ctx)}
 }
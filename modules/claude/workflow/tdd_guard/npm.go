@@ -1,6 +1,9 @@
 package tddguard
 
 import (
+	"context"
+
+	"code-template/helpers/transaction"
 	"code-template/services"
 )
 
@@ -11,15 +14,12 @@ const (
 
 var npmService = services.NPM
 
-// TddGuardPackage is the package definition for tdd-guard.
-var TddGuardPackage = services.Package{
-	Name:        tddGuardPackage,
-	InstallPath: tddGuardPackage,
-}
-
-// CheckNpmInstalled verifies npm is available in PATH.
-func CheckNpmInstalled() bool {
-	return npmService.IsAvailable()
+// TddGuardTool is tdd-guard at the version the module installs, unless
+// code-template.yml's tools section overrides it.
+var TddGuardTool = services.Tool{
+	Name:        tddGuardBinary,
+	Pinned:      "1.1.0",
+	VersionArgs: []string{"--version"},
 }
 
 // IsTddGuardInstalled checks if tdd-guard is available in PATH.
@@ -28,8 +28,40 @@ func IsTddGuardInstalled() bool {
 }
 
 // InstallTddGuard installs tdd-guard via npm install -g.
-func InstallTddGuard() error {
-	return npmService.Install(TddGuardPackage)
+func InstallTddGuard(ctx context.Context) error {
+	return npmService.Install(ctx, pinnedPackage())
+}
+
+// pinnedPackage returns tdd-guard at the version TddGuardTool pins, so
+// teammates install the same release.
+func pinnedPackage() services.Package {
+	return TddGuardTool.Package(tddGuardPackage)
+}
+
+// toolMismatch describes how the tdd-guard in PATH differs from the pinned
+// version; "" if it matches or isn't installed.
+func toolMismatch() string {
+	if !IsTddGuardInstalled() {
+		return ""
+	}
+	return TddGuardTool.Mismatch("")
+}
+
+// hasPinnedTddGuard reports whether the tdd-guard in PATH is the pinned
+// version, so installing it can be skipped.
+func hasPinnedTddGuard() bool {
+	return IsTddGuardInstalled() && toolMismatch() == ""
+}
+
+// reinstallStep installs the pinned tdd-guard over a release of another
+// version. It is skipped when the installed version matches.
+func reinstallStep(ctx context.Context) transaction.Step {
+	return transaction.Step{
+		Name:    "install pinned tdd-guard via npm",
+		Skip:    func() bool { return toolMismatch() == "" },
+		Do:      func() error { return InstallTddGuard(ctx) },
+		Effects: []transaction.Effect{transaction.RunsCommand(npmService.InstallCommand(pinnedPackage()), "")},
+	}
 }
 
 // Note: We intentionally do NOT provide an uninstall function for npm packages
@@ -1,34 +1,24 @@
 package gotstwwailsreact
 
 import (
+	"context"
 	"os/exec"
 	"runtime"
 
+	"code-template/helpers/transaction"
+	"code-template/models"
 	"code-template/services"
 )
 
-const (
-	wailsBinary  = "wails"
-	wailsInstall = "github.com/wailsapp/wails/v2/cmd/wails@latest"
-)
+const wailsBinary = "wails"
 
 var goService = services.Go
 var npmService = services.NPM
 
-// WailsPackage is the package definition for wails CLI.
-var WailsPackage = services.Package{
-	Name:        wailsBinary,
-	InstallPath: wailsInstall,
-}
-
-// CheckGoInstalled verifies that Go is available in PATH.
-func CheckGoInstalled() bool {
-	return goService.IsAvailable()
-}
-
-// CheckNpmInstalled verifies that npm is available in PATH.
-func CheckNpmInstalled() bool {
-	return npmService.IsAvailable()
+// wailsPackage returns the Wails CLI at the version services.WailsTool
+// pins, unless code-template.yml's tools section overrides it.
+func wailsPackage() services.Package {
+	return services.WailsTool.Package(services.WailsInstallPath)
 }
 
 // IsWailsInstalled checks if wails CLI is available in PATH.
@@ -38,57 +28,52 @@ func IsWailsInstalled() bool {
 }
 
 // InstallWailsCLI installs wails CLI globally using go install.
-func InstallWailsCLI() error {
-	cmd := exec.Command("go", "install", wailsInstall)
-	return cmd.Run()
+func InstallWailsCLI(ctx context.Context) error {
+	cmd := exec.Command("go", "install", wailsPackage().InstallPath)
+	return services.Run(ctx, cmd)
 }
 
-// CheckGccInstalled verifies gcc is available in PATH.
-func CheckGccInstalled() bool {
-	_, err := exec.LookPath("gcc")
-	return err == nil
+// toolMismatch describes how the wails in PATH differs from the pinned
+// version; "" if it matches or isn't installed.
+func toolMismatch() string {
+	if !IsWailsInstalled() {
+		return ""
+	}
+	return services.WailsTool.Mismatch("")
 }
 
-// CheckPkgConfig verifies pkg-config is available.
-func CheckPkgConfig() bool {
-	_, err := exec.LookPath("pkg-config")
-	return err == nil
+// hasPinnedWails reports whether the wails in PATH is the pinned version,
+// so installing it can be skipped.
+func hasPinnedWails() bool {
+	return IsWailsInstalled() && toolMismatch() == ""
+}
+
+// reinstallStep installs the pinned Wails CLI over a release of another
+// version. It is skipped when the installed version matches.
+func reinstallStep(ctx context.Context) transaction.Step {
+	return transaction.Step{
+		Name:    "install pinned Wails CLI",
+		Skip:    func() bool { return toolMismatch() == "" },
+		Do:      func() error { return InstallWailsCLI(ctx) },
+		Effects: []transaction.Effect{transaction.RunsCommand("go install "+wailsPackage().InstallPath, "")},
+	}
 }
 
 // CheckSystemLibrary checks if a library exists via pkg-config.
 func CheckSystemLibrary(pkgName string) bool {
-	cmd := exec.Command("pkg-config", "--exists", pkgName)
-	return cmd.Run() == nil
+	return services.LibraryRequirement(pkgName, "").Found()
 }
 
-// CheckLinuxDependencies verifies GTK and WebKit are installed.
-// Returns (ok, missingDep) where missingDep describes what's missing.
-func CheckLinuxDependencies() (bool, string) {
-	if runtime.GOOS != "linux" {
-		return true, ""
-	}
-
-	// Check gcc
-	if !CheckGccInstalled() {
-		return false, "gcc (install build-essential or base-devel)"
-	}
-
-	// Check pkg-config (needed to verify libraries)
-	if !CheckPkgConfig() {
-		return false, "pkg-config"
-	}
-
-	// Check GTK3
-	if !CheckSystemLibrary("gtk+-3.0") {
-		return false, "libgtk-3-dev"
-	}
-
-	// Check WebKit (try 4.1 first for newer distros, then 4.0)
-	if !CheckSystemLibrary("webkit2gtk-4.1") && !CheckSystemLibrary("webkit2gtk-4.0") {
-		return false, "libwebkit2gtk-4.0-dev or libwebkit2gtk-4.1-dev"
-	}
-
-	return true, ""
+// linuxRequirements are the system dependencies Wails builds against on
+// Linux. Newer distros only ship webkit2gtk-4.1, older ones 4.0.
+var linuxRequirements = []models.Requirement{
+	services.CommandRequirement("gcc", "install build-essential or base-devel", "--version"),
+	services.CommandRequirement("pkg-config", "install pkg-config", "--version"),
+	services.LibraryRequirement("gtk+-3.0", "install libgtk-3-dev"),
+	services.AnyRequirement("webkit2gtk", "install libwebkit2gtk-4.1-dev or libwebkit2gtk-4.0-dev",
+		services.LibraryRequirement("webkit2gtk-4.1", ""),
+		services.LibraryRequirement("webkit2gtk-4.0", ""),
+	),
 }
 
 // NeedsWebkit41BuildTag returns true if the system has webkit2gtk-4.1 but not 4.0.
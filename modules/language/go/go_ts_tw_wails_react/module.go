@@ -1,11 +1,19 @@
 package gotstwwailsreact
 
 import (
+	"context"
 	_ "embed"
-	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
 	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
 )
 
 const (
@@ -23,10 +31,12 @@ var Module = &WailsReactTSModule{
 }
 
 type WailsReactTSModule struct {
-	Name     string
-	Version  int
-	Category string
-	Path     string
+	Name      string
+	Version   int
+	Category  string
+	Path      string
+	Requires  []string
+	Conflicts []string
 }
 
 func (m *WailsReactTSModule) GetName() string {
@@ -49,18 +59,64 @@ func (m *WailsReactTSModule) GetKey() string {
 	return moduleKey
 }
 
+func (m *WailsReactTSModule) GetRequires() []string {
+	return m.Requires
+}
+
+func (m *WailsReactTSModule) GetConflicts() []string {
+	return m.Conflicts
+}
+
+// ManagedFiles returns the frontend config files written from embedded content.
+func (m *WailsReactTSModule) ManagedFiles() []models.ManagedFile {
+	return m.ManagedFilesAt(m.Version)
+}
+
+// ManagedFilesAt returns the config files as shipped in a given version.
+func (m *WailsReactTSModule) ManagedFilesAt(version int) []models.ManagedFile {
+	switch version {
+	case 1:
+		return configFiles()
+	}
+	return nil
+}
+
+// Requirements returns what the Wails project needs on the machine: Go and
+// npm, plus on Linux the C toolchain and the GTK and WebKit libraries.
+func (m *WailsReactTSModule) Requirements() []models.Requirement {
+	reqs := []models.Requirement{services.GoRequirement, services.NpmRequirement}
+	if runtime.GOOS == "linux" {
+		reqs = append(reqs, linuxRequirements...)
+	}
+	return reqs
+}
+
+// Binaries returns nil; the Wails CLI is installed globally, not into .bin/.
+func (m *WailsReactTSModule) Binaries() []string {
+	return nil
+}
+
+// NpmPackages returns the packages install adds to the frontend.
+func (m *WailsReactTSModule) NpmPackages() []models.NpmPackage {
+	return []models.NpmPackage{
+		{Name: typescriptPackage, Dir: frontendDir},
+		{Name: tailwindPackage, Dir: frontendDir},
+	}
+}
+
 // IsInstalled checks all conditions:
 // 1. wails.json exists
 // 2. frontend/package.json exists
 // 3. code-template.yml has wails-react-ts entry
+// 4. The files and binaries code-template.lock records still exist
 func (m *WailsReactTSModule) IsInstalled() bool {
 	// Check 1: wails.json exists
-	if _, err := os.Stat(wailsJSONFile); os.IsNotExist(err) {
+	if _, err := os.Stat(project.Path(wailsJSONFile)); os.IsNotExist(err) {
 		return false
 	}
 
 	// Check 2: frontend/package.json exists
-	if _, err := os.Stat(frontendPackageJSON); os.IsNotExist(err) {
+	if _, err := os.Stat(project.Path(frontendPackageJSON)); os.IsNotExist(err) {
 		return false
 	}
 
@@ -70,99 +126,124 @@ func (m *WailsReactTSModule) IsInstalled() bool {
 		return false
 	}
 
-	return true
+	// Check 4: What code-template.lock records is still there
+	return lockfile.Intact(moduleKey)
 }
 
-// Install performs installation steps with rollback on failure.
-func (m *WailsReactTSModule) Install() bool {
-	// Step 1: Check Go is installed
-	if !CheckGoInstalled() {
-		return false
-	}
-
-	// Step 2: Check npm is installed
-	if !CheckNpmInstalled() {
-		return false
-	}
-
-	// Step 3: Check Linux system dependencies (gcc, gtk, webkit)
-	if ok, missing := CheckLinuxDependencies(); !ok {
-		fmt.Printf("Missing Linux dependency: %s\n", missing)
-		fmt.Println("Run 'wails doctor' for installation instructions")
-		return false
-	}
+// Detect reports whether the project is a Wails project, which wails.json
+// marks.
+func (m *WailsReactTSModule) Detect() bool {
+	_, err := os.Stat(project.Path(wailsJSONFile))
+	return err == nil
+}
 
-	// Step 4: Install Wails CLI if not present
-	if !IsWailsInstalled() {
-		if err := InstallWailsCLI(); err != nil {
-			return false
+// Steps returns the transaction steps for installing or uninstalling the Wails project.
+// Undoing the scaffold step removes everything wails init created, so later
+// steps that only edit scaffolded files need no undo of their own.
+func (m *WailsReactTSModule) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		projectName := getProjectName()
+
+		var scaffoldEffects []transaction.Effect
+		scaffoldEffects = append(scaffoldEffects, transaction.RunsCommand(strings.Join(scaffoldArgs(projectName), " "), ""))
+		for _, path := range scaffoldedPaths {
+			scaffoldEffects = append(scaffoldEffects, transaction.WritesFile(path))
 		}
-	}
-
-	// Step 5: Scaffold Wails project
-	projectName := getProjectName()
-	if err := ScaffoldProject(projectName); err != nil {
-		return false
-	}
 
-	// Step 6: Configure webkit2gtk-4.1 if needed (newer Linux distros)
-	if NeedsWebkit41BuildTag() {
-		if err := ConfigureWebkit41(); err != nil {
-			RollbackScaffold()
-			return false
+		var configEffects []transaction.Effect
+		for _, f := range configFiles() {
+			configEffects = append(configEffects, transaction.WritesFile(f.Path))
 		}
-	}
-
-	// Step 7: Copy config files to frontend/
-	if err := CopyConfigFiles(); err != nil {
-		RollbackScaffold()
-		return false
-	}
-
-	// Step 8: Add Tailwind import to main.tsx
-	if err := AddTailwindImport(); err != nil {
-		RollbackConfigFiles()
-		RollbackScaffold()
-		return false
-	}
 
-	// Step 9: Upgrade TypeScript to latest (required for tsconfig.json)
-	if err := UpgradeTypeScript(); err != nil {
-		RollbackConfigFiles()
-		RollbackScaffold()
-		return false
+		steps := transaction.CheckRequirements(m.Requirements())
+		return append(steps, []transaction.Step{
+			{
+				Name:    "install Wails CLI",
+				Skip:    hasPinnedWails,
+				Do:      func() error { return InstallWailsCLI(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand("go install "+wailsPackage().InstallPath, "")},
+			},
+			{
+				Name: "scaffold Wails project",
+				Do: func() error {
+					return ScaffoldProject(ctx, projectName)
+				},
+				Undo: func() error {
+					RollbackScaffold()
+					return nil
+				},
+				Effects: scaffoldEffects,
+			},
+			{
+				// Newer Linux distros only ship webkit2gtk-4.1
+				Name:    "configure webkit2gtk-4.1",
+				Skip:    func() bool { return !NeedsWebkit41BuildTag() },
+				Do:      ConfigureWebkit41,
+				Effects: []transaction.Effect{transaction.EditsFile(wailsJSONFile, "set build:tags: webkit2_41")},
+			},
+			{
+				Name: "copy config files to frontend/",
+				Do:   CopyConfigFiles,
+				Undo: func() error {
+					RollbackConfigFiles()
+					return nil
+				},
+				Effects: configEffects,
+			},
+			{
+				Name:    "add Tailwind import to main.tsx",
+				Do:      AddTailwindImport,
+				Effects: []transaction.Effect{transaction.EditsFile(filepath.Join(frontendDir, "src", "main.tsx"), "import ./index.css")},
+			},
+			{
+				// Required because tsconfig.json uses TS 5.0+ features
+				Name:    "upgrade TypeScript to latest",
+				Do:      func() error { return UpgradeTypeScript(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand(strings.Join(upgradeTypeScriptArgs(), " "), frontendDir)},
+			},
+			{
+				Name:    "install Tailwind CSS and dependencies",
+				Do:      func() error { return InstallTailwind(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand(strings.Join(installTailwindArgs(), " "), frontendDir)},
+			},
+			{
+				Name:    "install frontend dependencies",
+				Do:      func() error { return InstallFrontendDeps(ctx) },
+				Effects: []transaction.Effect{transaction.RunsCommand(strings.Join(installFrontendArgs, " "), frontendDir)},
+			},
+			transaction.SetKey(codeTemplateFileName, moduleKey, m.Version),
+		}...)
+	case transaction.ActionUninstall:
+		// Only remove entry from code-template.yml
+		// Do NOT delete project files as user may have written code
+		return []transaction.Step{
+			transaction.RemoveKey(codeTemplateFileName, moduleKey),
+		}
 	}
+	return nil
+}
 
-	// Step 10: Install Tailwind CSS and dependencies
-	if err := InstallTailwind(); err != nil {
-		RollbackConfigFiles()
-		RollbackScaffold()
-		return false
-	}
+// Install performs installation steps with rollback on failure.
+func (m *WailsReactTSModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, moduleKey, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
+}
 
-	// Step 11: Install frontend dependencies
-	if err := InstallFrontendDeps(); err != nil {
-		RollbackConfigFiles()
-		RollbackScaffold()
-		return false
-	}
+// Uninstall removes only the code-template.yml entry (preserves user code).
+func (m *WailsReactTSModule) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(moduleKey, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
+}
 
-	// Step 12: Add entry to code-template.yml
-	if err := yamlhelper.SetKey(codeTemplateFileName, moduleKey, m.Version); err != nil {
-		RollbackConfigFiles()
-		RollbackScaffold()
-		return false
+// OutdatedTools reports the wails CLI in PATH if it isn't the pinned
+// version.
+func (m *WailsReactTSModule) OutdatedTools() []string {
+	if mismatch := toolMismatch(); mismatch != "" {
+		return []string{mismatch}
 	}
-
-	return true
+	return nil
 }
 
-// Uninstall removes only the code-template.yml entry (preserves user code).
-func (m *WailsReactTSModule) Uninstall() bool {
-	// Only remove entry from code-template.yml
-	// Do NOT delete project files as user may have written code
-	if err := yamlhelper.RemoveKey(codeTemplateFileName, moduleKey); err != nil {
-		return false
-	}
-	return true
+// ToolSteps returns the step that installs the pinned Wails CLI.
+func (m *WailsReactTSModule) ToolSteps(ctx context.Context) []transaction.Step {
+	return []transaction.Step{reinstallStep(ctx)}
 }
@@ -1,12 +1,18 @@
 package gotstwwailsreact
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/models"
+	"code-template/services"
 )
 
 //go:embed configs/tsconfig.json
@@ -23,109 +29,130 @@ var indexCSS []byte
 
 const frontendDir = "frontend"
 
-// getProjectName returns the current directory name as the project name.
-func getProjectName() string {
-	wd, err := os.Getwd()
-	if err != nil {
-		return "myapp"
-	}
-	return filepath.Base(wd)
+// npm packages added to the frontend during install.
+const (
+	typescriptPackage = "typescript"
+	tailwindPackage   = "@tailwindcss/postcss"
+)
+
+var installFrontendArgs = []string{"npm", "install"}
+
+// upgradeTypeScriptArgs returns the npm command that upgrades TypeScript.
+func upgradeTypeScriptArgs() []string {
+	return []string{"npm", "install", npmSpec(typescriptPackage, "latest"), "--save-dev"}
 }
 
-// ScaffoldProject runs wails init to scaffold a new React+TypeScript project.
-func ScaffoldProject(name string) error {
-	cmd := exec.Command("wails", "init", "-n", name, "-t", "react-ts", "-d", ".")
-	return cmd.Run()
+// installTailwindArgs returns the npm command that installs Tailwind CSS.
+func installTailwindArgs() []string {
+	return []string{"npm", "install", npmSpec(tailwindPackage, ""), "--save-dev"}
 }
 
-// CopyConfigFiles writes the embedded config files to the frontend directory.
-func CopyConfigFiles() error {
-	// Write tsconfig.json
-	tsconfigPath := filepath.Join(frontendDir, "tsconfig.json")
-	if err := os.WriteFile(tsconfigPath, tsconfigJSON, 0644); err != nil {
-		return err
+// npmSpec returns a frontend package at the version recorded in
+// code-template.lock, or at fallback (if any) when it isn't locked.
+func npmSpec(name, fallback string) string {
+	version := fallback
+	if entry, ok := lockfile.Get(moduleKey); ok {
+		if locked, ok := entry.Package(name, frontendDir); ok {
+			version = locked.Version
+		}
 	}
-
-	// Write tailwind.config.js
-	tailwindPath := filepath.Join(frontendDir, "tailwind.config.js")
-	if err := os.WriteFile(tailwindPath, tailwindConfigJS, 0644); err != nil {
-		os.Remove(tsconfigPath)
-		return err
+	if version == "" {
+		return name
 	}
+	return name + "@" + version
+}
 
-	// Write postcss.config.js
-	postcssPath := filepath.Join(frontendDir, "postcss.config.js")
-	if err := os.WriteFile(postcssPath, postcssConfigJS, 0644); err != nil {
-		os.Remove(tsconfigPath)
-		os.Remove(tailwindPath)
-		return err
-	}
+// scaffoldedPaths lists what wails init creates and RollbackScaffold removes.
+var scaffoldedPaths = []string{"wails.json", "main.go", "app.go", frontendDir, "build"}
 
-	// Write index.css with Tailwind directives
-	cssPath := filepath.Join(frontendDir, "src", "index.css")
-	if err := os.WriteFile(cssPath, indexCSS, 0644); err != nil {
-		os.Remove(tsconfigPath)
-		os.Remove(tailwindPath)
-		os.Remove(postcssPath)
-		return err
+// getProjectName returns the project root's directory name as the project name.
+func getProjectName() string {
+	return filepath.Base(project.Root())
+}
+
+// scaffoldArgs returns the wails init command line for a project name.
+func scaffoldArgs(name string) []string {
+	return []string{"wails", "init", "-n", name, "-t", "react-ts", "-d", "."}
+}
+
+// ScaffoldProject runs wails init to scaffold a new React+TypeScript project.
+func ScaffoldProject(ctx context.Context, name string) error {
+	args := scaffoldArgs(name)
+	cmd := exec.Command(args[0], args[1:]...)
+	return services.Run(ctx, cmd)
+}
+
+// configFiles returns the embedded config files and their destinations.
+func configFiles() []models.ManagedFile {
+	return []models.ManagedFile{
+		{Path: filepath.Join(frontendDir, "tsconfig.json"), Content: tsconfigJSON},
+		{Path: filepath.Join(frontendDir, "tailwind.config.js"), Content: tailwindConfigJS},
+		{Path: filepath.Join(frontendDir, "postcss.config.js"), Content: postcssConfigJS},
+		// index.css carries the Tailwind directives
+		{Path: filepath.Join(frontendDir, "src", "index.css"), Content: indexCSS},
 	}
+}
 
+// CopyConfigFiles writes the embedded config files to the frontend directory.
+// Files already written are removed again if a later write fails.
+func CopyConfigFiles() error {
+	var written []string
+	for _, f := range configFiles() {
+		if err := os.WriteFile(project.Path(f.Path), f.Content, 0644); err != nil {
+			for _, path := range written {
+				os.Remove(project.Path(path))
+			}
+			return err
+		}
+		written = append(written, f.Path)
+	}
 	return nil
 }
 
 // UpgradeTypeScript upgrades TypeScript to latest version in the frontend directory.
 // Required because tsconfig.json uses TS 5.0+ features (moduleResolution: bundler).
-func UpgradeTypeScript() error {
-	cmd := exec.Command("npm", "install", "typescript@latest", "--save-dev")
+func UpgradeTypeScript(ctx context.Context) error {
+	args := upgradeTypeScriptArgs()
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Dir = frontendDir
-	return cmd.Run()
+	return services.Run(ctx, cmd)
 }
 
 // InstallTailwind installs Tailwind CSS v4 PostCSS plugin in the frontend directory.
-func InstallTailwind() error {
-	cmd := exec.Command("npm", "install", "@tailwindcss/postcss", "--save-dev")
+func InstallTailwind(ctx context.Context) error {
+	args := installTailwindArgs()
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Dir = frontendDir
-	return cmd.Run()
+	return services.Run(ctx, cmd)
 }
 
 // InstallFrontendDeps runs npm install in the frontend directory.
-func InstallFrontendDeps() error {
-	cmd := exec.Command("npm", "install")
+func InstallFrontendDeps(ctx context.Context) error {
+	cmd := exec.Command(installFrontendArgs[0], installFrontendArgs[1:]...)
 	cmd.Dir = frontendDir
-	return cmd.Run()
+	return services.Run(ctx, cmd)
 }
 
 // RollbackConfigFiles removes the config files written to frontend/.
 func RollbackConfigFiles() {
-	os.Remove(filepath.Join(frontendDir, "tsconfig.json"))
-	os.Remove(filepath.Join(frontendDir, "tailwind.config.js"))
-	os.Remove(filepath.Join(frontendDir, "postcss.config.js"))
-	os.Remove(filepath.Join(frontendDir, "src", "index.css"))
+	for _, f := range configFiles() {
+		os.Remove(project.Path(f.Path))
+	}
 }
 
-// RollbackScaffold removes all files created by wails init.
+// RollbackScaffold removes all files created by wails init
+// (wails.json, main.go, app.go, frontend/ and build/).
 func RollbackScaffold() {
-	// Remove wails.json
-	os.Remove("wails.json")
-
-	// Remove main.go (wails generated)
-	os.Remove("main.go")
-
-	// Remove app.go (wails generated)
-	os.Remove("app.go")
-
-	// Remove frontend/ directory
-	os.RemoveAll(frontendDir)
-
-	// Remove build/ directory
-	os.RemoveAll("build")
+	for _, path := range scaffoldedPaths {
+		os.RemoveAll(project.Path(path))
+	}
 }
 
 // ConfigureWebkit41 updates wails.json to use webkit2gtk-4.1 build tags.
 // This is required on newer Linux distros that only have webkit2gtk-4.1.
 func ConfigureWebkit41() error {
 	// Read existing wails.json
-	data, err := os.ReadFile("wails.json")
+	data, err := os.ReadFile(project.Path(wailsJSONFile))
 	if err != nil {
 		return err
 	}
@@ -145,14 +172,14 @@ func ConfigureWebkit41() error {
 		return err
 	}
 
-	return os.WriteFile("wails.json", output, 0644)
+	return os.WriteFile(project.Path(wailsJSONFile), output, 0644)
 }
 
 // AddTailwindImport updates main.tsx to import index.css for Tailwind.
 func AddTailwindImport() error {
 	mainTsxPath := filepath.Join(frontendDir, "src", "main.tsx")
 
-	data, err := os.ReadFile(mainTsxPath)
+	data, err := os.ReadFile(project.Path(mainTsxPath))
 	if err != nil {
 		return err
 	}
@@ -166,5 +193,5 @@ import './style.css'`
 
 	content = strings.Replace(content, oldImport, newImport, 1)
 
-	return os.WriteFile(mainTsxPath, []byte(content), 0644)
+	return os.WriteFile(project.Path(mainTsxPath), []byte(content), 0644)
 }
@@ -1,25 +1,25 @@
 package golangci_lint
 
 import (
+	"context"
+
+	"code-template/helpers/transaction"
 	"code-template/services"
 )
 
 const (
 	golangciBinary  = "golangci-lint"
-	golangciInstall = "github.com/golangci/golangci-lint/v2/cmd/golangci-lint@latest"
+	golangciInstall = "github.com/golangci/golangci-lint/v2/cmd/golangci-lint"
 )
 
 var goService = services.Go
 
-// GolangciPackage is the package definition for golangci-lint.
-var GolangciPackage = services.Package{
+// GolangciTool is golangci-lint at the version golangci installs, unless
+// code-template.yml's tools section overrides it.
+var GolangciTool = services.Tool{
 	Name:        golangciBinary,
-	InstallPath: golangciInstall,
-}
-
-// CheckGoInstalled verifies that Go is available in PATH.
-func CheckGoInstalled() bool {
-	return goService.IsAvailable()
+	Pinned:      "v2.8.0",
+	VersionArgs: []string{"--version"},
 }
 
 // EnsureBinDir creates the .bin directory if it doesn't exist.
@@ -27,34 +27,63 @@ func EnsureBinDir() error {
 	return goService.EnsureBinDir()
 }
 
+// RemoveBinDirIfEmpty removes .bin if nothing was installed into it.
+func RemoveBinDirIfEmpty() error {
+	return goService.CleanupBinDir()
+}
+
 // AreBinariesInstalled checks if golangci-lint is available (locally or globally).
+// Whether it is the pinned version is up to OutdatedTools.
 func AreBinariesInstalled() bool {
 	return goService.IsInstalled(golangciBinary)
 }
 
-// InstallBinaries installs golangci-lint if not already available, returns list of installed for rollback.
-func InstallBinaries() ([]string, error) {
-	var installed []string
+// pinnedPackage returns golangci-lint at the version GolangciTool pins, so
+// every checkout installs the same release.
+func pinnedPackage() services.Package {
+	return GolangciTool.Package(golangciInstall)
+}
 
-	// Only install golangci-lint if not available (locally or globally)
-	if !goService.IsInstalled(golangciBinary) {
-		if err := goService.Install(GolangciPackage); err != nil {
-			return installed, err
+// toolMismatch describes how the golangci-lint in use, go.mod's in tool
+// mode and otherwise .bin/'s if there is one, differs from the pinned
+// version; "" if it matches or isn't installed.
+func toolMismatch() string {
+	switch {
+	case goService.ToolMode():
+		if version, ok := goService.ToolVersion(golangciBinary); ok {
+			return GolangciTool.VersionMismatch(version)
 		}
-		installed = append(installed, golangciBinary)
+	case goService.IsInstalledLocally(golangciBinary):
+		return GolangciTool.Mismatch(goService.GetBinPath(golangciBinary))
+	case goService.IsInstalled(golangciBinary):
+		return GolangciTool.Mismatch("")
 	}
+	return ""
+}
+
+// hasPinnedBinary reports whether the golangci-lint in use is the pinned
+// version, so installing it can be skipped.
+func hasPinnedBinary() bool {
+	return AreBinariesInstalled() && toolMismatch() == ""
+}
 
-	return installed, nil
+// InstallBinaries installs golangci-lint into .bin/, or into go.mod in tool mode.
+func InstallBinaries(ctx context.Context) error {
+	return goService.Install(ctx, pinnedPackage())
 }
 
-// RollbackBinaries removes previously installed binaries.
-func RollbackBinaries(installed []string) {
-	for _, name := range installed {
-		goService.Uninstall(name)
+// reinstallStep installs the pinned golangci-lint over a build of another
+// version. It is skipped when the version in use matches.
+func reinstallStep(ctx context.Context) transaction.Step {
+	return transaction.Step{
+		Name:    "install pinned golangci-lint",
+		Skip:    func() bool { return toolMismatch() == "" },
+		Do:      func() error { return InstallBinaries(ctx) },
+		Effects: transaction.InstallsGoTool(pinnedPackage(), golangciBinary),
 	}
 }
 
 // RemoveAllBinaries removes golangci-lint from .bin/ (for uninstall).
-func RemoveAllBinaries() error {
-	return goService.Uninstall(golangciBinary)
+func RemoveAllBinaries(ctx context.Context) error {
+	return goService.Uninstall(ctx, golangciBinary)
 }
@@ -1,10 +1,17 @@
 package golangci_lint
 
 import (
+	"context"
 	_ "embed"
 	"os"
 
+	"code-template/helpers/gitignore"
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/helpers/transaction"
 	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
 )
 
 //go:embed golangci.yml
@@ -24,10 +31,12 @@ var Module = &GolangciLintModule{
 }
 
 type GolangciLintModule struct {
-	Name     string
-	Version  int
-	Category string
-	Path     string
+	Name      string
+	Version   int
+	Category  string
+	Path      string
+	Requires  []string
+	Conflicts []string
 }
 
 func (m *GolangciLintModule) GetName() string {
@@ -50,13 +59,53 @@ func (m *GolangciLintModule) GetKey() string {
 	return moduleKey
 }
 
+func (m *GolangciLintModule) GetRequires() []string {
+	return m.Requires
+}
+
+func (m *GolangciLintModule) GetConflicts() []string {
+	return m.Conflicts
+}
+
+// ManagedFiles returns the files golangci writes from embedded content.
+func (m *GolangciLintModule) ManagedFiles() []models.ManagedFile {
+	return m.ManagedFilesAt(m.Version)
+}
+
+// ManagedFilesAt returns the managed files as shipped in a given version.
+func (m *GolangciLintModule) ManagedFilesAt(version int) []models.ManagedFile {
+	switch version {
+	case 1:
+		return []models.ManagedFile{{Path: golangciFileName, Content: golangciConfig}}
+	}
+	return nil
+}
+
+// Requirements returns what golangci needs on the machine: Go, to build
+// golangci-lint.
+func (m *GolangciLintModule) Requirements() []models.Requirement {
+	return []models.Requirement{services.GoRequirement}
+}
+
+// Binaries returns the binaries golangci installs into .bin/, or as go.mod
+// tools in tool mode.
+func (m *GolangciLintModule) Binaries() []string {
+	return []string{golangciBinary}
+}
+
+// NpmPackages returns nil; golangci installs no npm packages.
+func (m *GolangciLintModule) NpmPackages() []models.NpmPackage {
+	return nil
+}
+
 // IsInstalled checks all conditions:
 // 1. .golangci.yml exists
 // 2. code-template.yml has golangci entry
-// 3. Required binaries are installed in .bin/
+// 3. golangci-lint is installed (.bin/, PATH, or go.mod tool in tool mode)
+// 4. The files and binaries code-template.lock records still exist
 func (m *GolangciLintModule) IsInstalled() bool {
 	// Check 1: .golangci.yml exists
-	if _, err := os.Stat(golangciFileName); os.IsNotExist(err) {
+	if _, err := os.Stat(project.Path(golangciFileName)); os.IsNotExist(err) {
 		return false
 	}
 
@@ -66,80 +115,94 @@ func (m *GolangciLintModule) IsInstalled() bool {
 		return false
 	}
 
-	// Check 3: Binaries are installed
+	// Check 3: golangci-lint is installed
 	if !AreBinariesInstalled() {
 		return false
 	}
 
-	return true
+	// Check 4: What code-template.lock records is still there
+	return lockfile.Intact(moduleKey)
 }
 
-// Install performs installation steps with rollback on failure.
-func (m *GolangciLintModule) Install() bool {
-	// Step 0: Check Go is installed
-	if !CheckGoInstalled() {
-		return false
-	}
-
-	// Step 1: Create .bin directory
-	if err := EnsureBinDir(); err != nil {
-		return false
-	}
-
-	// Step 2: Install binaries
-	installed, err := InstallBinaries()
-	if err != nil {
-		RollbackBinaries(installed)
-		return false
-	}
-
-	// Step 3: Add .bin/ to .gitignore
-	if err := AddToGitignore(); err != nil {
-		RollbackBinaries(installed)
-		return false
-	}
-
-	// Step 4: Copy golangci.yml to .golangci.yml
-	if err := os.WriteFile(golangciFileName, golangciConfig, 0644); err != nil {
-		RollbackBinaries(installed)
-		RemoveFromGitignore()
-		return false
-	}
+// Detect reports whether the project has a .golangci.yml, whether or not
+// code-template wrote it.
+func (m *GolangciLintModule) Detect() bool {
+	_, err := os.Stat(project.Path(golangciFileName))
+	return err == nil
+}
 
-	// Step 5: Add entry to code-template.yml
-	if err := yamlhelper.SetKey(codeTemplateFileName, moduleKey, m.Version); err != nil {
-		os.Remove(golangciFileName)
-		RollbackBinaries(installed)
-		RemoveFromGitignore()
-		return false
+// Steps returns the transaction steps for installing or uninstalling golangci.
+func (m *GolangciLintModule) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		return []transaction.Step{
+			transaction.CheckRequirement(services.GoRequirement),
+			{
+				Name: "create .bin directory",
+				Do:   EnsureBinDir,
+				Undo: RemoveBinDirIfEmpty,
+			},
+			{
+				// Only install golangci-lint if not available at the pinned
+				// version (locally or globally)
+				Name:    "install golangci-lint",
+				Skip:    hasPinnedBinary,
+				Do:      func() error { return InstallBinaries(ctx) },
+				Undo:    func() error { return RemoveAllBinaries(ctx) },
+				Effects: transaction.InstallsGoTool(pinnedPackage(), golangciBinary),
+			},
+			{
+				Name:    "add .bin/ to .gitignore",
+				Skip:    HasGitignoreEntry,
+				Do:      AddToGitignore,
+				Undo:    RemoveFromGitignore,
+				Effects: []transaction.Effect{transaction.EditsFile(gitignore.Path(), "add "+binDirEntry)},
+			},
+			transaction.WriteFile("copy golangci.yml", golangciFileName, golangciConfig),
+			transaction.SetKey(codeTemplateFileName, moduleKey, m.Version),
+		}
+	case transaction.ActionUninstall:
+		return []transaction.Step{
+			transaction.RemoveFile("remove .golangci.yml", golangciFileName),
+			transaction.RemoveKey(codeTemplateFileName, moduleKey),
+			{
+				// .bin/ is shared across a workspace; leave it to the last member
+				Name:    "remove golangci-lint binary",
+				Skip:    usedByOtherMembers,
+				Do:      func() error { return RemoveAllBinaries(ctx) },
+				Effects: transaction.UninstallsGoTool(golangciBinary),
+			},
+			{
+				Name:    "remove .bin/ from .gitignore",
+				Skip:    usedByOtherMembers,
+				Do:      RemoveFromGitignore,
+				Effects: []transaction.Effect{transaction.EditsFile(gitignore.Path(), "remove "+binDirEntry)},
+			},
+		}
 	}
+	return nil
+}
 
-	return true
+// Install performs installation steps with rollback on failure.
+func (m *GolangciLintModule) Install(ctx context.Context) error {
+	return transaction.Run(ctx, moduleKey, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
 }
 
 // Uninstall removes all installed components (best-effort cleanup).
-func (m *GolangciLintModule) Uninstall() bool {
-	success := true
-
-	// Step 1: Remove .golangci.yml
-	if err := os.Remove(golangciFileName); err != nil && !os.IsNotExist(err) {
-		success = false
-	}
-
-	// Step 2: Remove entry from code-template.yml
-	if err := yamlhelper.RemoveKey(codeTemplateFileName, moduleKey); err != nil {
-		success = false
-	}
-
-	// Step 3: Remove binaries from .bin/
-	if err := RemoveAllBinaries(); err != nil {
-		success = false
-	}
+func (m *GolangciLintModule) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(moduleKey, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
+}
 
-	// Step 4: Remove .bin/ from .gitignore
-	if err := RemoveFromGitignore(); err != nil {
-		success = false
+// OutdatedTools reports the golangci-lint in use if it isn't the pinned
+// version.
+func (m *GolangciLintModule) OutdatedTools() []string {
+	if mismatch := toolMismatch(); mismatch != "" {
+		return []string{mismatch}
 	}
+	return nil
+}
 
-	return success
+// ToolSteps returns the step that installs the pinned golangci-lint.
+func (m *GolangciLintModule) ToolSteps(ctx context.Context) []transaction.Step {
+	return []transaction.Step{reinstallStep(ctx)}
 }
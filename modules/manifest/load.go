@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"code-template/helpers/project"
+	"code-template/models"
+)
+
+//go:embed builtin
+var builtinFS embed.FS
+
+// LocalDir holds a project's own manifests.
+const LocalDir = ".code-template/modules"
+
+// manifestFileName names a manifest that sits in a directory with its
+// source files.
+const manifestFileName = "module.yml"
+
+// Builtin returns the modules whose manifests ship with code-template.
+func Builtin() ([]*Module, error) {
+	sub, err := fs.Sub(builtinFS, "builtin")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub, "builtin")
+}
+
+// Local returns the modules declared in .code-template/modules/, which a
+// workspace keeps at its root. A missing directory declares none.
+func Local() ([]*Module, error) {
+	dir := filepath.Join(project.WorkspaceRoot(), LocalDir)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return Load(os.DirFS(dir), LocalDir)
+}
+
+// Load reads every manifest in fsys: any <dir>/module.yml, with the files it
+// sources next to it, and any single-file <name>.yml at the top level.
+// dir names fsys in error messages.
+func Load(fsys fs.FS, dir string) ([]*Module, error) {
+	var modules []*Module
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isManifest(p) {
+			return nil
+		}
+		m, err := load(fsys, p)
+		if err != nil {
+			return &InvalidManifestError{File: path.Join(dir, p), Err: err}
+		}
+		modules = append(modules, m)
+		return nil
+	})
+	return modules, err
+}
+
+func isManifest(p string) bool {
+	if path.Base(p) == manifestFileName {
+		return true
+	}
+	ext := path.Ext(p)
+	return path.Dir(p) == "." && (ext == ".yml" || ext == ".yaml")
+}
+
+// load parses the manifest at p and reads the files it sources.
+func load(fsys fs.FS, p string) (*Module, error) {
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := path.Dir(p)
+	m := &Module{manifest: *manifest, previous: map[int]map[string][]byte{}}
+	for _, f := range manifest.Files {
+		content := []byte(f.Content)
+		if f.Source != "" {
+			if content, err = fs.ReadFile(fsys, path.Join(dir, f.Source)); err != nil {
+				return nil, fmt.Errorf("file %s: %w", f.Path, err)
+			}
+		}
+		filePath := filepath.FromSlash(f.Path)
+		m.files = append(m.files, models.ManagedFile{Path: filePath, Content: content})
+
+		for version, source := range f.Previous {
+			old, err := fs.ReadFile(fsys, path.Join(dir, source))
+			if err != nil {
+				return nil, fmt.Errorf("file %s at v%d: %w", f.Path, version, err)
+			}
+			if m.previous[version] == nil {
+				m.previous[version] = map[string][]byte{}
+			}
+			m.previous[version][filePath] = old
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Manifest declares a module in YAML. Every field but name, path and
+// version is optional; the generic Module turns the rest into install,
+// uninstall and upgrade steps.
+type Manifest struct {
+	Name      string   `yaml:"name"`
+	Key       string   `yaml:"key"`      // code-template.yml key, defaults to name
+	Path      string   `yaml:"path"`     // Tree path, e.g. "tasks/go/go_test_task"
+	Category  string   `yaml:"category"` // Defaults to the first segment of path
+	Version   int      `yaml:"version"`
+	Requires  []string `yaml:"requires"`
+	Conflicts []string `yaml:"conflicts"`
+	Commands  []string `yaml:"commands"` // Must be in PATH before installing
+	Files     []File   `yaml:"files"`
+	Tasks     []Task   `yaml:"tasks"`
+	Binaries  []Binary `yaml:"binaries"`
+	Npm       []Npm    `yaml:"npm"`
+	Gitignore []string `yaml:"gitignore"`
+	Hooks     []Hook   `yaml:"hooks"`
+}
+
+// File is a file the module writes. Its content comes from source, a path
+// relative to the manifest, or inline from content. Previous maps older
+// versions to the source they shipped, so updates can merge local edits;
+// versions not listed are taken to have shipped the current content.
+type File struct {
+	Path     string         `yaml:"path"`
+	Source   string         `yaml:"source"`
+	Content  string         `yaml:"content"`
+	Previous map[int]string `yaml:"previous"`
+}
+
+// Task is a task added to Taskfile.yml. "{{bin}}" in a command stands for
+// the .bin directory, which a workspace shares at its root; in go-tools
+// tool mode "{{bin}}/name" runs "go tool name".
+type Task struct {
+	Name string   `yaml:"name"`
+	Desc string   `yaml:"desc"`
+	Cmds []string `yaml:"cmds"`
+}
+
+// Binary is a Go binary installed into .bin/ with go install.
+type Binary struct {
+	Name    string `yaml:"name"`    // e.g. "golangci-lint"
+	Package string `yaml:"package"` // e.g. "github.com/golangci/golangci-lint/v2/cmd/golangci-lint@v2.8.0"
+}
+
+// Npm is an npm package, installed globally when Dir is empty and as a
+// devDependency of the project in Dir otherwise. Global packages are
+// considered installed when Bin is in PATH.
+type Npm struct {
+	Name string `yaml:"name"`
+	Bin  string `yaml:"bin"`
+	Dir  string `yaml:"dir"`
+}
+
+// Hook is a command hook added to .claude/settings.json.
+type Hook struct {
+	Event   string `yaml:"event"`
+	Matcher string `yaml:"matcher"`
+	Command string `yaml:"command"`
+}
+
+// InvalidManifestError reports a manifest that cannot be loaded.
+type InvalidManifestError struct {
+	File string
+	Err  error
+}
+
+func (e *InvalidManifestError) Error() string {
+	return fmt.Sprintf("manifest %s: %v", e.File, e.Err)
+}
+
+func (e *InvalidManifestError) Unwrap() error {
+	return e.Err
+}
+
+// Parse decodes a manifest, fills in defaults and validates it.
+// Unknown fields are rejected so typos don't silently do nothing.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalWithOptions(data, &m, yaml.DisallowUnknownField()); err != nil {
+		return nil, err
+	}
+	if m.Key == "" {
+		m.Key = m.Name
+	}
+	if m.Category == "" {
+		m.Category, _, _ = strings.Cut(m.Path, "/")
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	switch {
+	case m.Name == "":
+		return fmt.Errorf("name is required")
+	case m.Path == "":
+		return fmt.Errorf("path is required")
+	case m.Version < 1:
+		return fmt.Errorf("version must be 1 or higher")
+	}
+
+	seen := map[string]bool{}
+	for _, f := range m.Files {
+		if f.Path == "" {
+			return fmt.Errorf("file without a path")
+		}
+		if (f.Source == "") == (f.Content == "") {
+			return fmt.Errorf("file %s needs exactly one of source or content", f.Path)
+		}
+		if path.IsAbs(f.Path) || strings.HasPrefix(path.Clean(f.Path), "..") {
+			return fmt.Errorf("file %s must be inside the project", f.Path)
+		}
+		for version := range f.Previous {
+			if version < 1 || version >= m.Version {
+				return fmt.Errorf("file %s lists previous version %d, want 1 to %d", f.Path, version, m.Version-1)
+			}
+		}
+		if seen["file "+f.Path] {
+			return fmt.Errorf("file %s is listed twice", f.Path)
+		}
+		seen["file "+f.Path] = true
+	}
+	for _, t := range m.Tasks {
+		if t.Name == "" || len(t.Cmds) == 0 {
+			return fmt.Errorf("tasks need a name and cmds")
+		}
+		if seen["task "+t.Name] {
+			return fmt.Errorf("task %s is listed twice", t.Name)
+		}
+		seen["task "+t.Name] = true
+	}
+	for _, b := range m.Binaries {
+		if b.Name == "" || b.Package == "" {
+			return fmt.Errorf("binaries need a name and package")
+		}
+	}
+	for _, n := range m.Npm {
+		if n.Name == "" {
+			return fmt.Errorf("npm packages need a name")
+		}
+	}
+	for _, h := range m.Hooks {
+		if h.Event == "" || h.Command == "" {
+			return fmt.Errorf("hooks need an event and command")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuiltin_LoadsEveryManifest(t *testing.T) {
+	modules, err := Builtin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"go-test":         "task-go-test",
+		"go-lint":         "task-go-lint",
+		"ts-lint":         "task-ts-lint",
+		"ts-test":         "task-ts-test",
+		"dev":             "task-wails-dev",
+		"frontend-design": "skill-frontend-design",
+		"eslint":          "eslint",
+	}
+	if len(modules) != len(want) {
+		t.Fatalf("got %d modules, want %d", len(modules), len(want))
+	}
+	for _, m := range modules {
+		if want[m.GetName()] != m.GetKey() {
+			t.Errorf("%s has key %q, want %q", m.GetName(), m.GetKey(), want[m.GetName()])
+		}
+	}
+}
+
+func TestParse_FillsDefaults(t *testing.T) {
+	m, err := Parse([]byte("name: go-vet\npath: tasks/go/go_vet_task\nversion: 1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Key != "go-vet" || m.Category != "tasks" {
+		t.Errorf("got key %q, category %q", m.Key, m.Category)
+	}
+}
+
+func TestParse_RejectsUnknownFields(t *testing.T) {
+	_, err := Parse([]byte("name: go-vet\npath: tasks/go_vet\nversion: 1\ntask:\n  - name: vet\n"))
+	if err == nil {
+		t.Fatal("expected an error for the misspelled tasks field")
+	}
+}
+
+func TestLoad_ReadsSourcesAndPreviousVersions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lint/module.yml": {Data: []byte(`name: lint
+path: linting/lint
+version: 2
+files:
+  - path: lint.yml
+    source: lint.yml
+    previous:
+      1: lint.v1.yml
+  - path: notes.txt
+    content: "notes\n"
+`)},
+		"lint/lint.yml":    {Data: []byte("v2\n")},
+		"lint/lint.v1.yml": {Data: []byte("v1\n")},
+	}
+
+	modules, err := Load(fsys, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(modules) != 1 {
+		t.Fatalf("got %d modules, want 1", len(modules))
+	}
+	m := modules[0]
+
+	current := m.ManagedFiles()
+	if string(current[0].Content) != "v2\n" || string(current[1].Content) != "notes\n" {
+		t.Errorf("unexpected current files: %q, %q", current[0].Content, current[1].Content)
+	}
+	old := m.ManagedFilesAt(1)
+	if string(old[0].Content) != "v1\n" || string(old[1].Content) != "notes\n" {
+		t.Errorf("unexpected v1 files: %q, %q", old[0].Content, old[1].Content)
+	}
+	if m.ManagedFilesAt(3) != nil {
+		t.Error("expected no files for an unreleased version")
+	}
+}
+
+func TestLoad_ReportsMissingSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"skill.yml": {Data: []byte("name: skill\npath: claude/skill\nversion: 1\nfiles:\n  - path: SKILL.md\n    source: SKILL.md\n")},
+	}
+
+	_, err := Load(fsys, ".code-template/modules")
+	var invalid *InvalidManifestError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidManifestError, got %v", err)
+	}
+	if invalid.File != ".code-template/modules/skill.yml" {
+		t.Errorf("got file %q", invalid.File)
+	}
+}
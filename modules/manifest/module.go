@@ -0,0 +1,378 @@
+package manifest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"code-template/helpers/claude"
+	"code-template/helpers/lockfile"
+	"code-template/helpers/project"
+	"code-template/helpers/taskfile"
+	"code-template/helpers/transaction"
+	"code-template/helpers/workspace"
+	yamlhelper "code-template/helpers/yaml"
+	"code-template/models"
+	"code-template/services"
+)
+
+const codeTemplateFileName = "code-template.yml"
+
+// Module is a models.Module that carries out a Manifest.
+type Module struct {
+	manifest Manifest
+	files    []models.ManagedFile
+	previous map[int]map[string][]byte // Version → path → content shipped then
+}
+
+func (m *Module) GetName() string {
+	return m.manifest.Name
+}
+
+func (m *Module) GetCategory() string {
+	return m.manifest.Category
+}
+
+func (m *Module) GetPath() string {
+	return m.manifest.Path
+}
+
+func (m *Module) GetVersion() int {
+	return m.manifest.Version
+}
+
+func (m *Module) GetKey() string {
+	return m.manifest.Key
+}
+
+func (m *Module) GetRequires() []string {
+	return m.manifest.Requires
+}
+
+func (m *Module) GetConflicts() []string {
+	return m.manifest.Conflicts
+}
+
+// ManagedFiles returns the files the manifest writes.
+func (m *Module) ManagedFiles() []models.ManagedFile {
+	return m.ManagedFilesAt(m.manifest.Version)
+}
+
+// ManagedFilesAt returns the managed files as shipped in a given version,
+// using the manifest's previous sources where it lists them.
+func (m *Module) ManagedFilesAt(version int) []models.ManagedFile {
+	if version < 1 || version > m.manifest.Version {
+		return nil
+	}
+	files := make([]models.ManagedFile, len(m.files))
+	for i, f := range m.files {
+		if content, ok := m.previous[version][f.Path]; ok {
+			f.Content = content
+		}
+		files[i] = f
+	}
+	return files
+}
+
+// Binaries returns the Go binaries the manifest installs into .bin/.
+func (m *Module) Binaries() []string {
+	var names []string
+	for _, b := range m.manifest.Binaries {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// NpmPackages returns the npm packages the manifest installs.
+func (m *Module) NpmPackages() []models.NpmPackage {
+	var packages []models.NpmPackage
+	for _, n := range m.manifest.Npm {
+		packages = append(packages, models.NpmPackage{Name: n.Name, Dir: n.Dir})
+	}
+	return packages
+}
+
+// Requirements returns the commands the manifest requires, plus Go when it
+// installs binaries and npm when it installs npm packages.
+func (m *Module) Requirements() []models.Requirement {
+	var reqs []models.Requirement
+	for _, command := range m.manifest.Commands {
+		reqs = append(reqs, services.RequirementFor(command))
+	}
+	if len(m.manifest.Binaries) > 0 && !slices.Contains(m.manifest.Commands, "go") {
+		reqs = append(reqs, services.GoRequirement)
+	}
+	if len(m.manifest.Npm) > 0 && !slices.Contains(m.manifest.Commands, "npm") {
+		reqs = append(reqs, services.NpmRequirement)
+	}
+	return reqs
+}
+
+// IsInstalled checks:
+// 1. code-template.yml has the module's entry
+// 2. Every file exists
+// 3. Taskfile.yml has every task
+// 4. Every binary and global npm command is available
+// 5. Every hook is configured in .claude/settings.json
+func (m *Module) IsInstalled() bool {
+	// Check 1: code-template.yml entry
+	hasEntry, err := yamlhelper.HasKey(codeTemplateFileName, m.manifest.Key)
+	if err != nil || !hasEntry {
+		return false
+	}
+
+	// Check 2: files exist
+	for _, f := range m.files {
+		if _, err := os.Stat(project.Path(f.Path)); err != nil {
+			return false
+		}
+	}
+
+	// Check 3: Taskfile.yml tasks
+	for _, t := range m.manifest.Tasks {
+		hasTask, err := taskfile.HasTask(t.Name)
+		if err != nil || !hasTask {
+			return false
+		}
+	}
+
+	// Check 4: binaries and npm commands
+	for _, b := range m.manifest.Binaries {
+		if !services.Go.IsInstalled(b.Name) {
+			return false
+		}
+	}
+	for _, n := range m.manifest.Npm {
+		if n.Dir == "" && n.Bin != "" && !services.NPM.IsInstalled(n.Bin) {
+			return false
+		}
+	}
+
+	// Check 5: hooks
+	for _, h := range m.manifest.Hooks {
+		if !claude.HasHook(claudeHook(h)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Detect reports whether every file, task and hook the manifest declares is
+// already in place. Binaries and npm packages are shared with other tools,
+// so they don't count; a manifest declaring none of the three is never
+// detected.
+func (m *Module) Detect() bool {
+	if len(m.files) == 0 && len(m.manifest.Tasks) == 0 && len(m.manifest.Hooks) == 0 {
+		return false
+	}
+	for _, f := range m.files {
+		if _, err := os.Stat(project.Path(f.Path)); err != nil {
+			return false
+		}
+	}
+	for _, t := range m.manifest.Tasks {
+		if hasTask, err := taskfile.HasTask(t.Name); err != nil || !hasTask {
+			return false
+		}
+	}
+	for _, h := range m.manifest.Hooks {
+		if !claude.HasHook(claudeHook(h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Steps returns the transaction steps for installing or uninstalling the
+// module. Uninstalling leaves npm packages in place, like the hand-written
+// modules do.
+func (m *Module) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		steps := m.toolSteps(ctx)
+		for _, f := range m.files {
+			steps = append(steps, transaction.WriteFile("write "+f.Path, f.Path, f.Content))
+		}
+		for _, h := range m.manifest.Hooks {
+			steps = append(steps, transaction.AddHook(claudeHook(h)))
+		}
+		for _, t := range m.manifest.Tasks {
+			steps = append(steps, transaction.AddTask(t.Name, t.Desc, taskCmds(t)))
+		}
+		return append(steps, transaction.SetKey(codeTemplateFileName, m.manifest.Key, m.manifest.Version))
+	case transaction.ActionUninstall:
+		var steps []transaction.Step
+		for _, t := range m.manifest.Tasks {
+			steps = append(steps, transaction.RemoveTask(t.Name))
+		}
+		for _, h := range m.manifest.Hooks {
+			steps = append(steps, transaction.RemoveHook(claudeHook(h)))
+		}
+		for _, f := range m.files {
+			steps = append(steps, transaction.RemoveFile("remove "+f.Path, f.Path))
+		}
+		for _, b := range m.manifest.Binaries {
+			steps = append(steps, transaction.Step{
+				Name:    "remove " + b.Name + " binary",
+				Skip:    m.usedByOtherMembers,
+				Do:      func() error { return services.Go.Uninstall(ctx, b.Name) },
+				Effects: transaction.UninstallsGoTool(b.Name),
+			})
+		}
+		for _, entry := range m.manifest.Gitignore {
+			step := transaction.RemoveGitignoreEntry(entry)
+			step.Skip = m.usedByOtherMembers
+			steps = append(steps, step)
+		}
+		return append(steps, transaction.RemoveKey(codeTemplateFileName, m.manifest.Key))
+	}
+	return nil
+}
+
+// toolSteps checks the required commands and installs binaries, npm
+// packages and .gitignore entries. Each install is skipped when already
+// done, so the steps can be rerun on upgrade.
+func (m *Module) toolSteps(ctx context.Context) []transaction.Step {
+	steps := transaction.CheckRequirements(m.Requirements())
+
+	if len(m.manifest.Binaries) > 0 {
+		steps = append(steps,
+			transaction.Step{
+				Name: "create .bin directory",
+				Do:   services.Go.EnsureBinDir,
+				Undo: services.Go.CleanupBinDir,
+			},
+		)
+	}
+	for _, b := range m.manifest.Binaries {
+		pkg := m.pinnedBinary(b)
+		steps = append(steps, transaction.Step{
+			Name:    "install " + b.Name,
+			Skip:    func() bool { return services.Go.IsInstalled(b.Name) },
+			Do:      func() error { return services.Go.Install(ctx, pkg) },
+			Undo:    func() error { return services.Go.Uninstall(ctx, b.Name) },
+			Effects: transaction.InstallsGoTool(pkg, b.Name),
+		})
+	}
+
+	for _, n := range m.manifest.Npm {
+		pkg := m.pinnedNpm(n)
+		if n.Dir == "" {
+			steps = append(steps, transaction.Step{
+				Name:    "install " + n.Name + " via npm",
+				Skip:    func() bool { return n.Bin != "" && services.NPM.IsInstalled(n.Bin) },
+				Do:      func() error { return services.NPM.Install(ctx, pkg) },
+				Effects: []transaction.Effect{transaction.RunsCommand(services.NPM.InstallCommand(pkg), "")},
+			})
+			continue
+		}
+		steps = append(steps, transaction.Step{
+			Name:    "install " + n.Name + " in " + n.Dir,
+			Do:      func() error { return services.NPM.InstallDev(ctx, pkg, n.Dir) },
+			Effects: []transaction.Effect{transaction.RunsCommand(services.NPM.InstallDevCommand(pkg), n.Dir)},
+		})
+	}
+
+	for _, entry := range m.manifest.Gitignore {
+		steps = append(steps, transaction.AddGitignoreEntry(entry))
+	}
+	return steps
+}
+
+// Install performs installation with rollback on failure
+func (m *Module) Install(ctx context.Context) error {
+	return transaction.Run(ctx, m.manifest.Key, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
+}
+
+// Uninstall removes everything the manifest added except npm packages
+func (m *Module) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(m.manifest.Key, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
+}
+
+// UpgradeSteps returns the steps that upgrade an installation from version
+// from to from+1. A manifest only describes its latest version, so the last
+// hop brings tools, hooks and tasks in line with it and earlier hops do
+// nothing. Files are merged separately from ManagedFilesAt.
+func (m *Module) UpgradeSteps(ctx context.Context, from int) ([]transaction.Step, bool) {
+	if from < 1 || from >= m.manifest.Version {
+		return nil, false
+	}
+	if from+1 < m.manifest.Version {
+		return nil, true
+	}
+
+	steps := m.toolSteps(ctx)
+	for _, h := range m.manifest.Hooks {
+		steps = append(steps, transaction.AddHook(claudeHook(h)))
+	}
+	for _, t := range m.manifest.Tasks {
+		steps = append(steps, transaction.UpdateTask(t.Name, t.Desc, taskCmds(t)))
+	}
+	return steps, true
+}
+
+// usedByOtherMembers reports whether another workspace member still has
+// the module installed and so needs its shared binaries and .gitignore
+// entries.
+func (m *Module) usedByOtherMembers() bool {
+	return workspace.InstalledElsewhere(m.manifest.Key)
+}
+
+// taskCmds returns t's commands with "{{bin}}" replaced by the path of the
+// bin directory, e.g. "./.bin" or "../../.bin" in a workspace member. In
+// tool mode "{{bin}}/name" becomes "go tool name" instead.
+func taskCmds(t Task) []string {
+	bin := filepath.ToSlash(services.Go.GetBinDir())
+	if !strings.HasPrefix(bin, "../") {
+		bin = "./" + bin
+	}
+	cmds := make([]string, len(t.Cmds))
+	for i, cmd := range t.Cmds {
+		if services.Go.ToolMode() {
+			cmd = binCommand.ReplaceAllString(cmd, "go tool $1")
+		}
+		cmds[i] = strings.ReplaceAll(cmd, "{{bin}}", bin)
+	}
+	return cmds
+}
+
+// binCommand matches a binary run from the bin directory in a task command.
+var binCommand = regexp.MustCompile(`\{\{bin\}\}/(\S+)`)
+
+// pinnedBinary returns b's package at the version recorded in
+// code-template.lock, so every checkout installs the same build.
+func (m *Module) pinnedBinary(b Binary) services.Package {
+	pkg := services.Package{Name: b.Name, InstallPath: b.Package}
+	entry, ok := lockfile.Get(m.manifest.Key)
+	if !ok {
+		return pkg
+	}
+	locked, ok := entry.Binary(services.Go.GetBinPath(b.Name))
+	if !ok || !strings.HasPrefix(locked.Version, "v") {
+		// Unlocked, or a local "(devel)" build that can't be reinstalled
+		return pkg
+	}
+	return pkg.At(locked.Version)
+}
+
+// pinnedNpm returns n at the version recorded in code-template.lock.
+func (m *Module) pinnedNpm(n Npm) services.Package {
+	pkg := services.Package{Name: n.Name}
+	entry, ok := lockfile.Get(m.manifest.Key)
+	if !ok {
+		return pkg
+	}
+	locked, ok := entry.Package(n.Name, n.Dir)
+	if !ok {
+		return pkg
+	}
+	return pkg.At(locked.Version)
+}
+
+func claudeHook(h Hook) claude.Hook {
+	return claude.Hook{Event: h.Event, Matcher: h.Matcher, Command: h.Command}
+}
@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	yamlhelper "code-template/helpers/yaml"
+)
+
+const referencePlugin = "code-template/plugins/code-template-module-editorconfig"
+
+// buildReference builds the reference plugin into dir.
+func buildReference(t *testing.T, dir string) string {
+	t.Helper()
+	exe := filepath.Join(dir, ExecutablePrefix+"editorconfig")
+	out, err := exec.Command("go", "build", "-o", exe, referencePlugin).CombinedOutput()
+	if err != nil {
+		t.Fatalf("build reference plugin: %v\n%s", err, out)
+	}
+	return exe
+}
+
+// pluginUnderTest returns the plugin the conformance tests check:
+// $CODE_TEMPLATE_PLUGIN if set, so plugin authors can run
+//
+//	CODE_TEMPLATE_PLUGIN=/abs/path/to/plugin go test ./modules/plugin -run Conformance
+//
+// and the reference plugin otherwise.
+func pluginUnderTest(t *testing.T) string {
+	if exe := os.Getenv("CODE_TEMPLATE_PLUGIN"); exe != "" {
+		return exe
+	}
+	return buildReference(t, t.TempDir())
+}
+
+func TestConformance(t *testing.T) {
+	exe := pluginUnderTest(t)
+	t.Chdir(t.TempDir())
+
+	m, err := New(exe)
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	call := func(operation string, from int) (*Response, error) {
+		return Call(t.Context(), exe, Request{Operation: operation, From: from})
+	}
+	installed := func() bool {
+		resp, err := call(OpIsInstalled, 0)
+		if err != nil {
+			t.Fatalf("is-installed: %v", err)
+		}
+		return resp.Installed
+	}
+
+	if installed() {
+		t.Fatal("is-installed reports true in an empty project")
+	}
+	if _, err := call(OpInstall, 0); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !installed() {
+		t.Fatal("is-installed reports false after install")
+	}
+	if m.GetVersion() > 1 {
+		if _, err := call(OpUpgrade, m.GetVersion()-1); err != nil {
+			t.Fatalf("upgrade from v%d: %v", m.GetVersion()-1, err)
+		}
+	}
+	if _, err := call(OpUninstall, 0); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if installed() {
+		t.Fatal("is-installed reports true after uninstall")
+	}
+	if _, err := call(OpUninstall, 0); err != nil {
+		t.Fatalf("uninstall of an uninstalled module: %v", err)
+	}
+
+	if _, err := call("no-such-operation", 0); err == nil {
+		t.Error("unknown operation succeeded")
+	}
+	if _, err := callRaw(exe, `{"protocol": 999, "operation": "describe"}`); err == nil {
+		t.Error("request for an unknown protocol succeeded")
+	}
+}
+
+// callRaw sends input as is and fails like Call on an error response.
+func callRaw(exe, input string) (*Response, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(exe)
+	cmd.Stdin = bytes.NewReader([]byte(input))
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+func TestModule_InstallsThroughPlugin(t *testing.T) {
+	dir := t.TempDir()
+	buildReference(t, filepath.Join(dir, LocalDir))
+	t.Chdir(dir)
+	t.Setenv("PATH", "")
+
+	modules, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(modules) != 1 {
+		t.Fatalf("got %d modules, want 1", len(modules))
+	}
+	m := modules[0]
+	if m.GetName() != "editorconfig" || m.GetKey() != "editorconfig" || m.GetCategory() != "editor" {
+		t.Errorf("unexpected description: %+v", m.desc)
+	}
+
+	if err := m.Install(t.Context()); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !m.IsInstalled() {
+		t.Error("expected module to be installed")
+	}
+	if hasKey, _ := yamlhelper.HasKey(codeTemplateFileName, "editorconfig"); !hasKey {
+		t.Error("expected code-template.yml entry")
+	}
+
+	if err := m.Uninstall(t.Context()); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if m.IsInstalled() {
+		t.Error("expected module to be uninstalled")
+	}
+	if _, err := os.Stat(".editorconfig"); !os.IsNotExist(err) {
+		t.Error("expected .editorconfig to be removed")
+	}
+}
+
+func TestDiscover_PrefersLocalPlugins(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	pathDir := filepath.Join(dir, "path")
+	t.Setenv("PATH", pathDir)
+
+	write := func(path string, mode os.FileMode) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(LocalDir, ExecutablePrefix+"lint"), 0755)
+	write(filepath.Join(pathDir, ExecutablePrefix+"lint"), 0755)
+	write(filepath.Join(pathDir, ExecutablePrefix+"docs"), 0755)
+	write(filepath.Join(pathDir, ExecutablePrefix+"notes.txt"), 0644)
+	write(filepath.Join(pathDir, "golangci-lint"), 0755)
+
+	got := Discover()
+	want := []string{
+		filepath.Join(LocalDir, ExecutablePrefix+"lint"),
+		filepath.Join(pathDir, ExecutablePrefix+"docs"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"code-template/helpers/project"
+	"code-template/services"
+)
+
+// ExecutablePrefix starts the name of every plugin executable.
+const ExecutablePrefix = "code-template-module-"
+
+// LocalDir holds a project's own plugins, at the workspace root in a
+// workspace. They take precedence over plugins of the same name on PATH.
+const LocalDir = ".code-template/plugins"
+
+// Call runs one operation of the plugin at exe, in the project root, and
+// returns its response. The plugin is killed once ctx is cancelled.
+func Call(ctx context.Context, exe string, req Request) (*Response, error) {
+	return call(ctx, exe, req, services.Run)
+}
+
+// call is Call with the function that runs the plugin.
+func call(ctx context.Context, exe string, req Request, run func(context.Context, *exec.Cmd) error) (*Response, error) {
+	req.Protocol = ProtocolVersion
+	req.Root = project.Root()
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(exe)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &out
+	wrap := func(err error) error {
+		return &PluginError{Plugin: filepath.Base(exe), Operation: req.Operation, Err: err}
+	}
+	if err := run(ctx, cmd); err != nil {
+		return nil, wrap(err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, wrap(fmt.Errorf("invalid response: %w", err))
+	}
+	if resp.Protocol != ProtocolVersion {
+		return nil, wrap(&ProtocolError{Got: resp.Protocol})
+	}
+	if resp.Error != "" {
+		return nil, wrap(errors.New(resp.Error))
+	}
+	return &resp, nil
+}
+
+// Discover returns the plugin executables in .code-template/plugins/ and on
+// PATH, in that order. When two share a name the first one wins.
+func Discover() []string {
+	dirs := append([]string{filepath.Join(project.WorkspaceRoot(), LocalDir)}, filepath.SplitList(os.Getenv("PATH"))...)
+
+	seen := make(map[string]bool)
+	var found []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".exe")
+			if !strings.HasPrefix(name, ExecutablePrefix) || seen[name] {
+				continue
+			}
+			exe := filepath.Join(dir, entry.Name())
+			if !isExecutable(exe) {
+				continue
+			}
+			seen[name] = true
+			found = append(found, exe)
+		}
+	}
+	return found
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.HasSuffix(path, ".exe")
+	}
+	return info.Mode()&0111 != 0
+}
+
+// Load describes every discovered plugin and wraps it as a module. A plugin
+// that fails to describe itself is left out, with a *PluginError for it in
+// skipped, so one broken executable doesn't stop code-template.
+func Load() (modules []*Module, skipped []error) {
+	for _, exe := range Discover() {
+		m, err := New(exe)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		modules = append(modules, m)
+	}
+	return modules, skipped
+}
+
+// New asks the plugin at exe to describe itself and wraps it as a module.
+// Describing is bounded like a requirement check, since every command
+// does it for every plugin, and like one belongs to no operation.
+func New(exe string) (*Module, error) {
+	resp, err := call(context.Background(), exe, Request{Operation: OpDescribe}, services.RunProbe)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Module == nil {
+		return nil, &PluginError{Plugin: filepath.Base(exe), Operation: OpDescribe, Err: errors.New("no module in response")}
+	}
+	desc := *resp.Module
+	if err := desc.normalize(); err != nil {
+		return nil, &PluginError{Plugin: filepath.Base(exe), Operation: OpDescribe, Err: err}
+	}
+	return &Module{exe: exe, desc: desc}, nil
+}
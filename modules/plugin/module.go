@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"path/filepath"
+
+	"code-template/helpers/transaction"
+	yamlhelper "code-template/helpers/yaml"
+)
+
+const codeTemplateFileName = "code-template.yml"
+
+// Module is a models.Module backed by a plugin executable.
+type Module struct {
+	exe  string
+	desc Description
+}
+
+func (m *Module) GetName() string {
+	return m.desc.Name
+}
+
+func (m *Module) GetCategory() string {
+	return m.desc.Category
+}
+
+func (m *Module) GetPath() string {
+	return m.desc.Path
+}
+
+func (m *Module) GetVersion() int {
+	return m.desc.Version
+}
+
+func (m *Module) GetKey() string {
+	return m.desc.Key
+}
+
+func (m *Module) GetRequires() []string {
+	return m.desc.Requires
+}
+
+func (m *Module) GetConflicts() []string {
+	return m.desc.Conflicts
+}
+
+// Executable returns the path of the plugin executable.
+func (m *Module) Executable() string {
+	return m.exe
+}
+
+// IsInstalled checks:
+// 1. code-template.yml has the module's entry
+// 2. The plugin reports itself installed
+func (m *Module) IsInstalled() bool {
+	// Check 1: code-template.yml entry, before starting the plugin
+	hasEntry, err := yamlhelper.HasKey(codeTemplateFileName, m.desc.Key)
+	if err != nil || !hasEntry {
+		return false
+	}
+
+	// Check 2: ask the plugin
+	resp, err := Call(context.Background(), m.exe, Request{Operation: OpIsInstalled})
+	return err == nil && resp.Installed
+}
+
+// Detect asks the plugin whether it finds itself installed, regardless of
+// code-template.yml.
+func (m *Module) Detect() bool {
+	resp, err := Call(context.Background(), m.exe, Request{Operation: OpIsInstalled})
+	return err == nil && resp.Installed
+}
+
+// Steps returns the transaction steps for installing or uninstalling the
+// module. A failed install is undone by asking the plugin to uninstall.
+func (m *Module) Steps(ctx context.Context, action string) []transaction.Step {
+	switch action {
+	case transaction.ActionInstall:
+		step := m.step(ctx, OpInstall, 0)
+		step.Undo = m.step(ctx, OpUninstall, 0).Do
+		return []transaction.Step{
+			step,
+			transaction.SetKey(codeTemplateFileName, m.desc.Key, m.desc.Version),
+		}
+	case transaction.ActionUninstall:
+		return []transaction.Step{
+			m.step(ctx, OpUninstall, 0),
+			transaction.RemoveKey(codeTemplateFileName, m.desc.Key),
+		}
+	}
+	return nil
+}
+
+// Install performs installation with rollback on failure
+func (m *Module) Install(ctx context.Context) error {
+	return transaction.Run(ctx, m.desc.Key, transaction.ActionInstall, m.Steps(ctx, transaction.ActionInstall))
+}
+
+// Uninstall asks the plugin to uninstall and removes the code-template.yml entry
+func (m *Module) Uninstall(ctx context.Context) error {
+	return transaction.RunBestEffort(m.desc.Key, transaction.ActionUninstall, m.Steps(ctx, transaction.ActionUninstall))
+}
+
+// UpgradeSteps returns the step that asks the plugin to upgrade from
+// version from to from+1. Whether the plugin can is only known by trying.
+func (m *Module) UpgradeSteps(ctx context.Context, from int) ([]transaction.Step, bool) {
+	if from < 1 || from >= m.desc.Version {
+		return nil, false
+	}
+	return []transaction.Step{m.step(ctx, OpUpgrade, from)}, true
+}
+
+// step returns a step that runs one plugin operation under ctx.
+func (m *Module) step(ctx context.Context, operation string, from int) transaction.Step {
+	command := filepath.Base(m.exe) + " " + operation
+	return transaction.Step{
+		Name: "run " + command,
+		Do: func() error {
+			_, err := Call(ctx, m.exe, Request{Operation: operation, From: from})
+			return err
+		},
+		Effects: []transaction.Effect{transaction.RunsCommand(command, "")},
+	}
+}
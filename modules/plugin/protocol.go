@@ -0,0 +1,110 @@
+// Package plugin wraps external module executables as models.Module.
+//
+// A plugin is an executable named code-template-module-<name>, found in
+// .code-template/plugins/ or on PATH. Each operation starts the executable
+// once in the project root, writes one JSON Request to its stdin and reads
+// one JSON Response from its stdout. Anything the plugin writes to stderr is
+// kept for error messages. A plugin reports failure with a non-empty "error"
+// field or a non-zero exit status.
+//
+// Operations:
+//
+//	describe      → {"module": {"name", "key", "path", "category", "version", "requires", "conflicts"}}
+//	is-installed  → {"installed": true|false}
+//	install       → {}
+//	uninstall     → {}
+//	upgrade       → {} after upgrading from request "from" to from+1
+//
+// Every request and response carries "protocol"; a plugin must answer a
+// request for a protocol it doesn't speak with an error. code-template
+// itself records the module's version in code-template.yml, so plugins only
+// manage their own files and tools.
+package plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the protocol version code-template speaks.
+const ProtocolVersion = 1
+
+// Operations a plugin must handle.
+const (
+	OpDescribe    = "describe"
+	OpIsInstalled = "is-installed"
+	OpInstall     = "install"
+	OpUninstall   = "uninstall"
+	OpUpgrade     = "upgrade"
+)
+
+// Request is written to a plugin's stdin.
+type Request struct {
+	Protocol  int    `json:"protocol"`
+	Operation string `json:"operation"`
+	Root      string `json:"root"`           // Absolute project root, also the working directory
+	From      int    `json:"from,omitempty"` // upgrade only: the installed version
+}
+
+// Response is read from a plugin's stdout.
+type Response struct {
+	Protocol  int          `json:"protocol"`
+	Error     string       `json:"error,omitempty"`
+	Module    *Description `json:"module,omitempty"`    // describe only
+	Installed bool         `json:"installed,omitempty"` // is-installed only
+}
+
+// Description is what a plugin reports about its module.
+type Description struct {
+	Name      string   `json:"name"`
+	Key       string   `json:"key,omitempty"`      // code-template.yml key, defaults to name
+	Path      string   `json:"path"`               // Tree path, e.g. "tools/internal/lint"
+	Category  string   `json:"category,omitempty"` // Defaults to the first segment of path
+	Version   int      `json:"version"`
+	Requires  []string `json:"requires,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// normalize fills in defaults and validates a description.
+func (d *Description) normalize() error {
+	if d.Key == "" {
+		d.Key = d.Name
+	}
+	if d.Category == "" {
+		d.Category, _, _ = strings.Cut(d.Path, "/")
+	}
+	switch {
+	case d.Name == "":
+		return fmt.Errorf("name is required")
+	case d.Path == "":
+		return fmt.Errorf("path is required")
+	case d.Version < 1:
+		return fmt.Errorf("version must be 1 or higher")
+	}
+	return nil
+}
+
+// PluginError is returned when a plugin fails an operation or breaks the
+// protocol.
+type PluginError struct {
+	Plugin    string
+	Operation string
+	Err       error
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin %s %s: %v", e.Plugin, e.Operation, e.Err)
+}
+
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// ProtocolError is returned when a plugin speaks another protocol version.
+type ProtocolError struct {
+	Got int
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("speaks protocol v%d, want v%d", e.Got, ProtocolVersion)
+}
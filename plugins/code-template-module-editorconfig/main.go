@@ -0,0 +1,135 @@
+// Command code-template-module-editorconfig is the reference module plugin.
+// It manages an .editorconfig file and uses only the standard library, so
+// it can be copied as a starting point for plugins outside this repo.
+//
+// Install it by putting the binary on PATH or in .code-template/plugins/.
+// See package code-template/modules/plugin for the protocol.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	protocolVersion = 1
+	moduleVersion   = 2
+	configFile      = ".editorconfig"
+)
+
+// v1 shipped the base section; v2 added markdown.
+const (
+	baseConfig = `root = true
+
+[*]
+charset = utf-8
+end_of_line = lf
+insert_final_newline = true
+trim_trailing_whitespace = true
+`
+	markdownSection = `
+[*.md]
+trim_trailing_whitespace = false
+`
+)
+
+type request struct {
+	Protocol  int    `json:"protocol"`
+	Operation string `json:"operation"`
+	Root      string `json:"root"`
+	From      int    `json:"from,omitempty"`
+}
+
+type response struct {
+	Protocol  int          `json:"protocol"`
+	Error     string       `json:"error,omitempty"`
+	Module    *description `json:"module,omitempty"`
+	Installed bool         `json:"installed,omitempty"`
+}
+
+type description struct {
+	Name     string `json:"name"`
+	Key      string `json:"key,omitempty"`
+	Path     string `json:"path"`
+	Category string `json:"category,omitempty"`
+	Version  int    `json:"version"`
+}
+
+func main() {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid request: %v\n", err)
+		os.Exit(1)
+	}
+
+	resp := handle(req)
+	resp.Protocol = protocolVersion
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func handle(req request) response {
+	if req.Protocol != protocolVersion {
+		return fail(fmt.Errorf("unsupported protocol v%d", req.Protocol))
+	}
+
+	// The plugin is started in the project root; req.Root says so explicitly
+	path := filepath.Join(req.Root, configFile)
+
+	switch req.Operation {
+	case "describe":
+		return response{Module: &description{
+			Name:     "editorconfig",
+			Path:     "editor/editorconfig",
+			Category: "editor",
+			Version:  moduleVersion,
+		}}
+	case "is-installed":
+		_, err := os.Stat(path)
+		return response{Installed: err == nil}
+	case "install":
+		return fail(os.WriteFile(path, []byte(baseConfig+markdownSection), 0644))
+	case "uninstall":
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fail(err)
+		}
+		return response{}
+	case "upgrade":
+		return fail(upgrade(path, req.From))
+	}
+	return fail(fmt.Errorf("unknown operation %q", req.Operation))
+}
+
+// upgrade performs one version step, keeping local edits to the file.
+func upgrade(path string, from int) error {
+	if from != 1 {
+		return fmt.Errorf("no upgrade from v%d", from)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(data), "[*.md]") {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(markdownSection)
+	return err
+}
+
+// fail returns an empty response for a nil err and an error response otherwise.
+func fail(err error) response {
+	if err == nil {
+		return response{}
+	}
+	return response{Error: err.Error()}
+}
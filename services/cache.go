@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cacheManifest sits next to each cached binary and records what it is and
+// the hash it had when built.
+const cacheManifest = "entry.json"
+
+// cacheableVersion matches versions that always resolve to the same build,
+// such as "v2.8.0" or a pseudo-version; "latest" or a branch don't.
+var cacheableVersion = regexp.MustCompile(`^v\d+\.\d+\.\d+`)
+
+// CacheCorruptError is returned when a cached binary no longer has the hash
+// it was built with.
+type CacheCorruptError struct {
+	Path string
+	Want string
+	Got  string
+}
+
+func (e *CacheCorruptError) Error() string {
+	return fmt.Sprintf("%s has sha256 %s, want %s", e.Path, e.Got, e.Want)
+}
+
+// BinCache is a user-level cache of the tool binaries go install builds,
+// shared by every repo on the machine. Each build lives in
+// <Dir>/<package>@<version>/, so a repo whose tools are cached installs them
+// by linking, without building or network access.
+type BinCache struct {
+	Dir string
+}
+
+// CacheEntry is one cached build.
+type CacheEntry struct {
+	Package string    `json:"package" yaml:"package"` // Package path without version
+	Version string    `json:"version" yaml:"version"`
+	Binary  string    `json:"binary" yaml:"binary"` // Binary file name
+	SHA256  string    `json:"sha256" yaml:"sha256"`
+	Size    int64     `json:"size" yaml:"size"`
+	Built   time.Time `json:"built" yaml:"built"`
+	Used    time.Time `json:"used" yaml:"used"` // When a repo last installed it
+	Dir     string    `json:"-" yaml:"-"`
+}
+
+// Path returns the path of the cached binary.
+func (e CacheEntry) Path() string {
+	return filepath.Join(e.Dir, e.Binary)
+}
+
+func (e CacheEntry) String() string {
+	return e.Package + "@" + e.Version
+}
+
+// UserBinCache returns the cache in the user's cache directory
+// ($XDG_CACHE_HOME/code-template/bin on Linux).
+func UserBinCache() (*BinCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &BinCache{Dir: filepath.Join(dir, "code-template", "bin")}, nil
+}
+
+// splitVersion splits a Go install path into package and version. ok is
+// false unless the version can be cached.
+func splitVersion(installPath string) (pkg, version string, ok bool) {
+	i := strings.LastIndex(installPath, "@")
+	if i < 0 {
+		return installPath, "", false
+	}
+	pkg, version = installPath[:i], installPath[i+1:]
+	return pkg, version, cacheableVersion.MatchString(version)
+}
+
+// entryDir returns the directory a build of pkg at version is cached in.
+func (c *BinCache) entryDir(pkg, version string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(pkg)+"@"+version)
+}
+
+// Lookup returns the cached build of pkg, if there is one that still has
+// the hash it was built with.
+func (c *BinCache) Lookup(pkg Package) (CacheEntry, bool) {
+	path, version, ok := splitVersion(pkg.InstallPath)
+	if !ok {
+		return CacheEntry{}, false
+	}
+	entry, err := readCacheEntry(c.entryDir(path, version))
+	if err != nil || c.Verify(entry) != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Build runs go install for pkg into the cache and returns the new entry.
+// The build goes to a temporary directory first, so a failed or concurrent
+// build never leaves a half-written entry behind.
+func (c *BinCache) Build(ctx context.Context, pkg Package) (CacheEntry, error) {
+	path, version, ok := splitVersion(pkg.InstallPath)
+	if !ok {
+		return CacheEntry{}, fmt.Errorf("%s is not pinned to a version that can be cached", pkg.InstallPath)
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return CacheEntry{}, err
+	}
+	tmp, err := os.MkdirTemp(c.Dir, ".build-")
+	if err != nil {
+		return CacheEntry{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	cmd := exec.Command("go", "install", pkg.InstallPath)
+	cmd.Env = append(os.Environ(), "GOBIN="+tmp)
+	if err := Run(ctx, cmd); err != nil {
+		return CacheEntry{}, err
+	}
+	built, err := os.ReadDir(tmp)
+	if err != nil {
+		return CacheEntry{}, err
+	}
+	if len(built) != 1 {
+		return CacheEntry{}, fmt.Errorf("go install %s built %d files, want 1", pkg.InstallPath, len(built))
+	}
+
+	entry := CacheEntry{Package: path, Version: version, Binary: built[0].Name(), Built: time.Now(), Dir: tmp}
+	if entry.SHA256, entry.Size, err = hashFile(entry.Path()); err != nil {
+		return CacheEntry{}, err
+	}
+	entry.Used = entry.Built
+	if err := writeCacheEntry(entry); err != nil {
+		return CacheEntry{}, err
+	}
+
+	dir := c.entryDir(path, version)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return CacheEntry{}, err
+	}
+	os.RemoveAll(dir) // An entry that failed Lookup
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another process cached the same build first
+		if existing, ok := c.Lookup(pkg); ok {
+			return existing, nil
+		}
+		return CacheEntry{}, err
+	}
+	entry.Dir = dir
+	return entry, nil
+}
+
+// Link puts the cached binary at dest: as a hardlink where the file system
+// allows, as a copy elsewhere. It marks the entry as used.
+func (c *BinCache) Link(entry CacheEntry, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(entry.Path(), dest); err != nil {
+		if err := copyFile(entry.Path(), dest); err != nil {
+			return err
+		}
+	}
+	entry.Used = time.Now()
+	writeCacheEntry(entry) // Only Prune reads it; a read-only cache still links
+	return nil
+}
+
+// Verify checks that the cached binary still has the hash it was built
+// with, returning a *CacheCorruptError if not.
+func (c *BinCache) Verify(entry CacheEntry) error {
+	sum, _, err := hashFile(entry.Path())
+	if err != nil {
+		return err
+	}
+	if sum != entry.SHA256 {
+		return &CacheCorruptError{Path: entry.Path(), Want: entry.SHA256, Got: sum}
+	}
+	return nil
+}
+
+// List returns the cached builds, sorted by package and version. Entries
+// whose manifest can't be read are left out; Prune removes them.
+func (c *BinCache) List() ([]CacheEntry, error) {
+	entries := []CacheEntry{}
+	err := c.walk(func(dir string) error {
+		if entry, err := readCacheEntry(dir); err == nil {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Package != entries[j].Package {
+			return entries[i].Package < entries[j].Package
+		}
+		return entries[i].Version < entries[j].Version
+	})
+	return entries, err
+}
+
+// Prune removes the builds no repo installed for longer than olderThan, the
+// ones that fail Verify and unreadable entries. It returns what it removed,
+// by directory relative to the cache.
+func (c *BinCache) Prune(olderThan time.Duration) ([]string, error) {
+	removed := []string{}
+	var errs []error
+	err := c.walk(func(dir string) error {
+		entry, err := readCacheEntry(dir)
+		if err == nil && c.Verify(entry) == nil && time.Since(entry.Used) <= olderThan {
+			return nil
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		rel, _ := filepath.Rel(c.Dir, dir)
+		removed = append(removed, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(removed)
+	return removed, errors.Join(append(errs, err)...)
+}
+
+// walk calls fn for each entry directory in the cache, leaving out
+// unfinished builds. A cache that doesn't exist yet is empty.
+func (c *BinCache) walk(fn func(dir string) error) error {
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".build-") {
+			return filepath.SkipDir
+		}
+		if strings.Contains(d.Name(), "@") {
+			if err := fn(path); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func readCacheEntry(dir string) (CacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, cacheManifest))
+	if err != nil {
+		return CacheEntry{}, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, err
+	}
+	entry.Dir = dir
+	return entry, nil
+}
+
+func writeCacheEntry(entry CacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(entry.Dir, cacheManifest), append(data, '\n'), 0644)
+}
+
+// hashFile returns the hex SHA-256 and the size of a file.
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// copyFile copies an executable, writing to a temporary file first so dest
+// is never left half-written.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dest + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeGo puts a go on PATH whose install writes a script named after the
+// package into GOBIN and counts its builds in dir/builds.
+func fakeGo(t *testing.T, dir string) {
+	t.Helper()
+	script := "#!/bin/sh\necho built >> " + filepath.Join(dir, "builds") + "\n" +
+		"name=$(basename \"${2%@*}\")\nprintf '#!/bin/sh\\necho %s\\n' \"$2\" > \"$GOBIN/$name\"\nchmod +x \"$GOBIN/$name\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestGoService_InstallsPinnedPackagesFromCache(t *testing.T) {
+	tools := t.TempDir()
+	fakeGo(t, tools)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	service := NewGoService()
+	pkg := Package{Name: "lint", InstallPath: "example.com/cmd/lint@v1.2.3"}
+
+	for _, repo := range []string{t.TempDir(), t.TempDir()} {
+		t.Chdir(repo)
+		if err := service.Install(context.Background(), pkg); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(repo, ".bin", "lint")); err != nil {
+			t.Fatalf("expected .bin/lint in %s: %v", repo, err)
+		}
+	}
+	builds, _ := os.ReadFile(filepath.Join(tools, "builds"))
+	if string(builds) != "built\n" {
+		t.Errorf("expected one build for both repos, got %q", builds)
+	}
+
+	entries, err := service.Cache.List()
+	if err != nil || len(entries) != 1 || entries[0].String() != "example.com/cmd/lint@v1.2.3" {
+		t.Fatalf("expected the build to be listed, got %v (%v)", entries, err)
+	}
+	if err := service.Cache.Verify(entries[0]); err != nil {
+		t.Errorf("expected the build to verify: %v", err)
+	}
+
+	// A corrupt build fails Verify, is rebuilt on install and pruned
+	os.Remove(entries[0].Path())
+	os.WriteFile(entries[0].Path(), []byte("tampered"), 0755)
+	var corrupt *CacheCorruptError
+	if err := service.Cache.Verify(entries[0]); !errors.As(err, &corrupt) {
+		t.Errorf("expected *CacheCorruptError, got %v", err)
+	}
+	if removed, err := service.Cache.Prune(time.Hour); err != nil || len(removed) != 1 {
+		t.Errorf("expected the corrupt build to be pruned, got %v (%v)", removed, err)
+	}
+	if err := service.Install(context.Background(), pkg); err != nil {
+		t.Fatal(err)
+	}
+	builds, _ = os.ReadFile(filepath.Join(tools, "builds"))
+	if string(builds) != "built\nbuilt\n" {
+		t.Errorf("expected a rebuild after pruning, got %q", builds)
+	}
+	if removed, _ := service.Cache.Prune(time.Hour); len(removed) != 0 {
+		t.Errorf("expected a recently used build to be kept, got %v", removed)
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"code-template/helpers/project"
+)
+
+// CommandError is returned when an external command fails.
+// It carries the command line and whatever the command wrote to stderr.
+type CommandError struct {
+	Command string
+	Stderr  string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Command, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// waitDelay is how long Run waits for a killed command's children to
+// release its output before giving up on them.
+const waitDelay = 2 * time.Second
+
+// Run runs cmd and returns a *CommandError with captured stderr on failure.
+// If cmd.Stderr is already set, output is written there as well.
+// cmd runs in the project root unless cmd.Dir says otherwise; a relative
+// cmd.Dir is taken relative to the root.
+//
+// cmd is killed when ctx is cancelled, failing with ErrCancelled, or when
+// it outlives its timeout (see Timeout), failing with a *TimeoutError. When
+// ctx has a log (see WithLog), the command line, its output and any failure
+// are written there too.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	return run(ctx, cmd, 0)
+}
+
+// RunProbe is Run for a quick command that only asks something, such as a
+// plugin describing itself: it also fails once it runs longer than a
+// requirement check may, so a hanging command can't stall code-template.
+func RunProbe(ctx context.Context, cmd *exec.Cmd) error {
+	return run(ctx, cmd, probeTimeout)
+}
+
+// run is Run with the timeout capped at limit, unless limit is 0.
+func run(ctx context.Context, cmd *exec.Cmd, limit time.Duration) error {
+	cmd.Dir = project.Path(cmd.Dir)
+	command := strings.Join(cmd.Args, " ")
+	var stderr bytes.Buffer
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, cmd.Stderr)
+	} else {
+		cmd.Stderr = &stderr
+	}
+
+	log := logFrom(ctx)
+	var stdoutLines, stderrLines *lineWriter
+	if log != nil {
+		log.Println("$ " + command)
+		stdoutLines, stderrLines = &lineWriter{log: log}, &lineWriter{log: log}
+		if cmd.Stdout != nil {
+			cmd.Stdout = io.MultiWriter(cmd.Stdout, stdoutLines)
+		} else {
+			cmd.Stdout = stdoutLines
+		}
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderrLines)
+	}
+	fail := func(err error) error {
+		if log != nil {
+			stdoutLines.flush()
+			stderrLines.flush()
+			log.Println(fmt.Sprintf("✗ %v", err))
+		}
+		return &CommandError{
+			Command: command,
+			Stderr:  strings.TrimSpace(stderr.String()),
+			Err:     err,
+		}
+	}
+
+	timeout, err := Timeout(cmd.Path)
+	if err != nil {
+		return fail(err)
+	}
+	if limit > 0 {
+		timeout = min(timeout, limit)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if ctx.Err() != nil {
+		return fail(ErrCancelled)
+	}
+
+	cmd.WaitDelay = waitDelay
+	if err := cmd.Start(); err != nil {
+		return fail(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-done
+		err = ErrCancelled
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = &TimeoutError{Timeout: timeout}
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if log != nil {
+		stdoutLines.flush()
+		stderrLines.flush()
+	}
+	return nil
+}
+
+// Stderr returns the captured stderr of the first CommandError in err's
+// chain, or "" if there is none.
+func Stderr(err error) string {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Stderr
+	}
+	return ""
+}
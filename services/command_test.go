@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"code-template/models"
+)
+
+func TestRun_CapturesStderrOnFailure(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo boom >&2; exit 3")
+
+	err := Run(context.Background(), cmd)
+	if err == nil {
+		t.Fatal("expected error from failing command")
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected *CommandError, got %T", err)
+	}
+	if cmdErr.Stderr != "boom" {
+		t.Errorf("expected stderr %q, got %q", "boom", cmdErr.Stderr)
+	}
+
+	// Stderr must be reachable through a StepError wrapper
+	wrapped := &models.StepError{Step: 2, Name: "install golangci-lint", Err: err}
+	if got := Stderr(wrapped); got != "boom" {
+		t.Errorf("expected wrapped stderr %q, got %q", "boom", got)
+	}
+	if !strings.HasPrefix(wrapped.Error(), "Step 2: install golangci-lint: sh -c") {
+		t.Errorf("unexpected message: %s", wrapped.Error())
+	}
+}
+
+func TestRun_FailsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := Run(ctx, exec.Command("sleep", "5"))
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("expected ErrCancelled, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("expected the command to be killed, took %s", time.Since(start))
+	}
+}
+
+func TestRun_KillsCommandAfterConfiguredTimeout(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("code-template.yml", []byte("timeouts:\n  default: 1m\n  sleep: 100ms\n"), 0644)
+
+	if d, err := Timeout("/usr/bin/true"); err != nil || d != time.Minute {
+		t.Errorf("expected the default timeout of 1m, got %s (%v)", d, err)
+	}
+
+	err := Run(context.Background(), exec.Command("sleep", "5"))
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Timeout != 100*time.Millisecond {
+		t.Fatalf("expected a 100ms *TimeoutError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error to wrap context.DeadlineExceeded")
+	}
+
+	os.WriteFile("code-template.yml", []byte("timeouts:\n  sleep: soon\n"), 0644)
+	var invalid *InvalidTimeoutError
+	if err := Run(context.Background(), exec.Command("sleep", "0")); !errors.As(err, &invalid) {
+		t.Errorf("expected *InvalidTimeoutError, got %v", err)
+	}
+}
+
+func TestRun_WritesOutputToOperationLog(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	var followed []string
+	log, err := OpenLog("mod", func(line string) { followed = append(followed, line) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithLog(context.Background(), log)
+
+	if err := Run(ctx, exec.Command("sh", "-c", "echo out; echo err >&2; printf partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(log.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"$ sh -c echo out; echo err >&2; printf partial", "out", "err", "partial"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("expected log to contain %q, got:\n%s", line, data)
+		}
+	}
+	if len(followed) != 4 {
+		t.Errorf("expected every line to be followed, got %v", followed)
+	}
+
+	empty, err := OpenLog("idle", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty.Close()
+	if _, err := os.Stat(empty.Path); !os.IsNotExist(err) {
+		t.Error("expected an empty log to be removed")
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"code-template/helpers/project"
+	yamlhelper "code-template/helpers/yaml"
+)
+
+// DefaultTimeout bounds an external command that code-template.yml sets
+// no timeout for.
+const DefaultTimeout = 10 * time.Minute
+
+// probeTimeout bounds the quick commands that check a requirement.
+const probeTimeout = 10 * time.Second
+
+// timeoutsKey is the code-template.yml section that sets per-command
+// timeouts, keyed by command name, with "default" for the rest:
+//
+//	timeouts:
+//	  default: 10m
+//	  npm: 5m
+//	  go: 15m
+const timeoutsKey = "timeouts"
+
+// ErrCancelled is returned by commands and transactions that stopped
+// because their context was cancelled.
+var ErrCancelled = errors.New("operation cancelled")
+
+// TimeoutError is returned when a command outlives its timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// InvalidTimeoutError is returned when code-template.yml sets a timeout
+// that isn't a positive duration such as "90s" or "5m".
+type InvalidTimeoutError struct {
+	Command string
+	Value   any
+}
+
+func (e *InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("%s timeout for %s in %s is %v; want a duration such as \"5m\"", timeoutsKey, e.Command, project.ConfigFile, e.Value)
+}
+
+// Timeout returns the timeout for command, as set in the workspace root's
+// code-template.yml or DefaultTimeout.
+func Timeout(command string) (time.Duration, error) {
+	value, exists, err := yamlhelper.GetValue(filepath.Join(project.WorkspaceRoot(), project.ConfigFile), timeoutsKey)
+	if err != nil || !exists {
+		return DefaultTimeout, err
+	}
+	timeouts, ok := value.(map[string]any)
+	if !ok {
+		return 0, &InvalidTimeoutError{Command: command, Value: value}
+	}
+
+	for _, key := range []string{filepath.Base(command), "default"} {
+		raw, ok := timeouts[key]
+		if !ok {
+			continue
+		}
+		s, _ := raw.(string)
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return 0, &InvalidTimeoutError{Command: key, Value: raw}
+		}
+		return d, nil
+	}
+	return DefaultTimeout, nil
+}
+
+// probeContext returns a context for a requirement check. Checks are quick
+// and belong to no operation, so they are only bounded by probeTimeout.
+func probeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), probeTimeout)
+}
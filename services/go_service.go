@@ -1,9 +1,15 @@
 package services
 
 import (
+	"context"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"code-template/helpers/project"
 )
 
 const (
@@ -16,12 +22,19 @@ type GoService struct {
 	// BinDir is the directory where binaries are installed.
 	// Defaults to ".bin" if empty.
 	BinDir string
+
+	// Cache, if not nil, holds the builds of packages pinned to a version,
+	// which are linked into BinDir instead of being built for every repo.
+	Cache *BinCache
 }
 
-// NewGoService creates a new GoService with default settings.
+// NewGoService creates a new GoService with default settings, caching
+// builds in the user's cache directory when there is one.
 func NewGoService() *GoService {
+	cache, _ := UserBinCache()
 	return &GoService{
 		BinDir: defaultBinDir,
+		Cache:  cache,
 	}
 }
 
@@ -37,8 +50,12 @@ func (s *GoService) IsAvailable() bool {
 }
 
 // IsInstalled checks if a binary is installed.
-// Checks both the local bin directory and global PATH.
+// Checks both the local bin directory and global PATH, or in tool mode
+// whether go.mod has a tool directive for it.
 func (s *GoService) IsInstalled(binaryName string) bool {
+	if s.ToolMode() {
+		return s.HasTool(binaryName)
+	}
 	// Check local .bin/ first
 	if s.isInstalledLocally(binaryName) {
 		return true
@@ -48,80 +65,175 @@ func (s *GoService) IsInstalled(binaryName string) bool {
 	return err == nil
 }
 
-// IsInstalledLocally checks if a binary exists in the local bin directory.
+// IsInstalledLocally checks if a binary exists in the local bin directory,
+// or in tool mode whether go.mod has a tool directive for it.
 func (s *GoService) IsInstalledLocally(binaryName string) bool {
+	if s.ToolMode() {
+		return s.HasTool(binaryName)
+	}
 	return s.isInstalledLocally(binaryName)
 }
 
+// HasTool reports whether go.mod has a tool directive for binaryName,
+// whichever the mode.
+func (s *GoService) HasTool(binaryName string) bool {
+	mod, err := readGoMod()
+	if err != nil {
+		return false
+	}
+	_, ok := mod.tool(binaryName)
+	return ok
+}
+
 func (s *GoService) isInstalledLocally(binaryName string) bool {
-	binPath := filepath.Join(s.getBinDir(), binaryName)
-	_, err := os.Stat(binPath)
+	_, err := os.Stat(project.Path(s.GetBinPath(binaryName)))
 	return err == nil
 }
 
 // Install installs a Go package to the local bin directory.
-// Creates the bin directory if it doesn't exist.
-func (s *GoService) Install(pkg Package) error {
-	binDir := s.getBinDir()
+// Creates the bin directory if it doesn't exist. A package pinned to a
+// version is built into the cache, unless already there, and linked from
+// it, so installing cached tools needs neither a build nor the network.
+// In tool mode the package is added to go.mod with go get -tool instead.
+func (s *GoService) Install(ctx context.Context, pkg Package) error {
+	mode, err := s.Mode()
+	if err != nil {
+		return err
+	}
+	if mode == GoToolsTool {
+		if _, err := os.Stat(project.Path(GoModFile)); err != nil {
+			return fmt.Errorf("%s %s needs a %s: %w", GoToolsKey, GoToolsTool, GoModFile, err)
+		}
+		return Run(ctx, exec.Command("go", "get", "-tool", pkg.InstallPath))
+	}
+	if s.cacheable(pkg) {
+		entry, ok := s.Cache.Lookup(pkg)
+		if !ok {
+			var err error
+			if entry, err = s.Cache.Build(ctx, pkg); err != nil {
+				return err
+			}
+		}
+		return s.Cache.Link(entry, project.Path(s.GetBinPath(entry.Binary)))
+	}
+
+	binDir := project.Path(s.getBinDir())
 
 	// Ensure bin directory exists
 	if err := os.MkdirAll(binDir, 0755); err != nil {
 		return err
 	}
 
-	absDir, err := filepath.Abs(binDir)
-	if err != nil {
-		return err
+	cmd := exec.Command("go", "install", pkg.InstallPath)
+	cmd.Env = append(os.Environ(), "GOBIN="+binDir)
+	return Run(ctx, cmd)
+}
+
+// InstallCommand returns the command line Install runs for pkg, or how it
+// links pkg from the cache.
+func (s *GoService) InstallCommand(pkg Package) string {
+	if s.ToolMode() {
+		return "go get -tool " + pkg.InstallPath
+	}
+	if s.cacheable(pkg) {
+		if entry, ok := s.Cache.Lookup(pkg); ok {
+			return "ln " + entry.Path() + " " + s.GetBinPath(entry.Binary)
+		}
+		return "go install " + pkg.InstallPath + " (into " + s.Cache.Dir + ")"
 	}
+	return "GOBIN=" + s.getBinDir() + " go install " + pkg.InstallPath
+}
 
-	cmd := exec.Command("go", "install", pkg.InstallPath)
-	cmd.Env = append(os.Environ(), "GOBIN="+absDir)
-	return cmd.Run()
+// cacheable reports whether Install goes through the cache for pkg.
+func (s *GoService) cacheable(pkg Package) bool {
+	_, _, ok := splitVersion(pkg.InstallPath)
+	return s.Cache != nil && ok
 }
 
 // Uninstall removes a binary from the local bin directory.
-// Also cleans up the bin directory if it becomes empty.
-func (s *GoService) Uninstall(binaryName string) error {
-	binDir := s.getBinDir()
-	binPath := filepath.Join(binDir, binaryName)
-
-	err := os.Remove(binPath)
+// Also cleans up the bin directory if it becomes empty. In tool mode the
+// tool directive is dropped from go.mod instead.
+func (s *GoService) Uninstall(ctx context.Context, binaryName string) error {
+	if s.ToolMode() {
+		mod, err := readGoMod()
+		if err != nil {
+			return err
+		}
+		pkg, ok := mod.tool(binaryName)
+		if !ok {
+			return nil
+		}
+		return Run(ctx, exec.Command("go", "get", "-tool", pkg+"@none"))
+	}
+	err := os.Remove(project.Path(s.GetBinPath(binaryName)))
 	if os.IsNotExist(err) {
 		err = nil // Not an error if file doesn't exist
 	}
 
 	// Clean up empty bin directory
-	s.cleanupBinDir()
-
-	return err
+	return errors.Join(err, s.cleanupBinDir())
 }
 
-// EnsureBinDir creates the bin directory if it doesn't exist.
+// EnsureBinDir creates the bin directory if it doesn't exist. Tool mode
+// needs none.
 func (s *GoService) EnsureBinDir() error {
-	return os.MkdirAll(s.getBinDir(), 0755)
+	if s.ToolMode() {
+		return nil
+	}
+	return os.MkdirAll(project.Path(s.getBinDir()), 0755)
+}
+
+// CleanupBinDir removes the bin directory if it is empty. A missing bin
+// directory is not an error.
+func (s *GoService) CleanupBinDir() error {
+	return s.cleanupBinDir()
+}
+
+// BinaryInfo is the build information embedded in a Go binary.
+type BinaryInfo struct {
+	Package string // Main package path
+	Module  string // Main module path
+	Version string // Main module version, e.g. "v2.1.0"
 }
 
-// GetBinPath returns the full path to a binary in the bin directory.
+// GetBinaryInfo reads the build information of a binary in the bin directory.
+func (s *GoService) GetBinaryInfo(binaryName string) (BinaryInfo, error) {
+	info, err := buildinfo.ReadFile(project.Path(s.GetBinPath(binaryName)))
+	if err != nil {
+		return BinaryInfo{}, err
+	}
+	return BinaryInfo{Package: info.Path, Module: info.Main.Path, Version: info.Main.Version}, nil
+}
+
+// GetBinPath returns the path of a binary in the bin directory, relative to
+// the project root.
 func (s *GoService) GetBinPath(binaryName string) string {
 	return filepath.Join(s.getBinDir(), binaryName)
 }
 
+// GetBinDir returns the bin directory relative to the project root. In a
+// workspace the members share the one at the workspace root.
+func (s *GoService) GetBinDir() string {
+	return s.getBinDir()
+}
+
 func (s *GoService) getBinDir() string {
 	if s.BinDir == "" {
-		return defaultBinDir
+		return project.SharedPath(defaultBinDir)
 	}
-	return s.BinDir
+	return project.SharedPath(s.BinDir)
 }
 
-func (s *GoService) cleanupBinDir() {
-	binDir := s.getBinDir()
+func (s *GoService) cleanupBinDir() error {
+	binDir := project.Path(s.getBinDir())
 	entries, err := os.ReadDir(binDir)
-	if err != nil {
-		return
+	if os.IsNotExist(err) {
+		return nil
 	}
-	if len(entries) == 0 {
-		os.Remove(binDir)
+	if err != nil || len(entries) > 0 {
+		return err
 	}
+	return os.Remove(binDir)
 }
 
 // Global instance for convenience
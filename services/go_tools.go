@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"code-template/helpers/project"
+	yamlhelper "code-template/helpers/yaml"
+)
+
+// GoToolsKey is the code-template.yml key that chooses how GoService
+// installs tools for the repo:
+//
+//	go-tools: tool
+const GoToolsKey = "go-tools"
+
+// Ways GoService installs tools.
+const (
+	GoToolsBin  = "bin"  // Built into .bin/ with go install (default)
+	GoToolsTool = "tool" // Recorded as tool directives in go.mod with go get -tool, run with go tool
+)
+
+// GoModFile is the module file tool directives are recorded in, and
+// GoSumFile the checksums go get -tool adds.
+const (
+	GoModFile = "go.mod"
+	GoSumFile = "go.sum"
+)
+
+// InvalidGoToolsError is returned when code-template.yml sets go-tools to
+// something other than GoToolsBin or GoToolsTool.
+type InvalidGoToolsError struct {
+	Value any
+}
+
+func (e *InvalidGoToolsError) Error() string {
+	return fmt.Sprintf("%s in %s is %v; want %q or %q", GoToolsKey, project.ConfigFile, e.Value, GoToolsBin, GoToolsTool)
+}
+
+// Mode returns how s installs tools: GoToolsTool when the workspace root's
+// code-template.yml asks for it, GoToolsBin otherwise.
+func (s *GoService) Mode() (string, error) {
+	value, exists, err := yamlhelper.GetValue(filepath.Join(project.WorkspaceRoot(), project.ConfigFile), GoToolsKey)
+	if err != nil || !exists || value == nil {
+		return GoToolsBin, nil
+	}
+	switch value {
+	case GoToolsBin, GoToolsTool:
+		return value.(string), nil
+	}
+	return GoToolsBin, &InvalidGoToolsError{Value: value}
+}
+
+// ToolMode reports whether s records tools in go.mod instead of .bin/.
+func (s *GoService) ToolMode() bool {
+	mode, err := s.Mode()
+	return err == nil && mode == GoToolsTool
+}
+
+// ToolVersion returns the version go.mod requires the tool binaryName is
+// built from, if go.mod has a tool directive for it.
+func (s *GoService) ToolVersion(binaryName string) (string, bool) {
+	mod, err := readGoMod()
+	if err != nil {
+		return "", false
+	}
+	tool, ok := mod.tool(binaryName)
+	if !ok {
+		return "", false
+	}
+	// The requirement of the longest module path the tool's package is in
+	version, longest := "", ""
+	for module, v := range mod.requires {
+		if (tool == module || strings.HasPrefix(tool, module+"/")) && len(module) > len(longest) {
+			version, longest = v, module
+		}
+	}
+	return version, version != ""
+}
+
+// goMod is what GoService reads from go.mod: the packages of its tool
+// directives and the version of each required module.
+type goMod struct {
+	tools    []string
+	requires map[string]string
+}
+
+// tool returns the package of the tool directive whose binary is named
+// binaryName.
+func (m goMod) tool(binaryName string) (string, bool) {
+	for _, pkg := range m.tools {
+		if toolBinary(pkg) == binaryName {
+			return pkg, true
+		}
+	}
+	return "", false
+}
+
+// toolBinary returns the name go tool knows a package's binary by: its last
+// path element, or the one before a major version suffix such as "/v2".
+func toolBinary(pkg string) string {
+	name := path.Base(pkg)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(pkg))
+	}
+	return name
+}
+
+// readGoMod reads the tool and require directives of the project's go.mod.
+func readGoMod() (goMod, error) {
+	data, err := os.ReadFile(project.Path(GoModFile))
+	if err != nil {
+		return goMod{}, err
+	}
+	file, err := modfile.Parse(GoModFile, data, nil)
+	if err != nil {
+		return goMod{}, err
+	}
+
+	mod := goMod{requires: make(map[string]string, len(file.Require))}
+	for _, tool := range file.Tool {
+		mod.tools = append(mod.tools, tool.Path)
+	}
+	for _, req := range file.Require {
+		mod.requires[req.Mod.Path] = req.Mod.Version
+	}
+	return mod, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"os"
+	"testing"
+)
+
+func TestToolVersion_ReadsBlockDirectivesAndComments(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile(GoModFile, []byte(`module example.com/app // the app
+
+go 1.25.0
+
+tool (
+	github.com/golangci/golangci-lint/v2/cmd/golangci-lint // linter
+	golang.org/x/tools/cmd/goimports
+)
+
+require (
+	github.com/golangci/golangci-lint/v2 v2.1.0 // indirect
+	golang.org/x/tools v0.30.0
+)
+
+require golang.org/x/tools/cmd v0.31.0
+`), 0644)
+
+	if got, ok := Go.ToolVersion("golangci-lint"); !ok || got != "v2.1.0" {
+		t.Errorf("expected golangci-lint at v2.1.0, got %q, %v", got, ok)
+	}
+	if got, ok := Go.ToolVersion("goimports"); !ok || got != "v0.31.0" {
+		t.Errorf("expected the longest module path to win, got %q, %v", got, ok)
+	}
+	if _, ok := Go.ToolVersion("gofumpt"); ok {
+		t.Error("expected no version for a tool without a directive")
+	}
+}
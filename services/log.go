@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code-template/helpers/project"
+)
+
+// LogDir holds a log file per operation, named after when it started and
+// what it worked on.
+const LogDir = ".code-template/logs"
+
+// Log collects the output of the external commands an operation runs. Run
+// writes to the log of the context it is given (see WithLog), one line at a
+// time, so the output of commands running in parallel interleaves by line.
+type Log struct {
+	Path string
+
+	mu     sync.Mutex
+	file   *os.File
+	follow func(line string)
+	empty  bool
+}
+
+// OpenLog creates a log in LogDir for an operation on name. follow, if not
+// nil, is also given every line, as it is written.
+func OpenLog(name string, follow func(line string)) (*Log, error) {
+	dir := project.Path(LogDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name = strings.NewReplacer("/", "-", string(filepath.Separator), "-", " ", "-").Replace(name)
+	path := filepath.Join(dir, time.Now().Format("20060102-150405")+"-"+name+".log")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{Path: path, file: file, follow: follow, empty: true}, nil
+}
+
+// Println writes a line to the log.
+func (l *Log) Println(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	fmt.Fprintln(l.file, line)
+	l.empty = false
+	if l.follow != nil {
+		l.follow(line)
+	}
+}
+
+// Empty reports whether nothing was written to the log.
+func (l *Log) Empty() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.empty
+}
+
+// Close closes the log. A log nothing was written to is removed, so
+// operations that ran no commands leave no file behind.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	if l.empty {
+		return os.Remove(l.Path)
+	}
+	return err
+}
+
+// lineWriter splits what a command writes into lines for a Log. flush
+// writes whatever is left of an unterminated last line.
+type lineWriter struct {
+	log     *Log
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.log.Println(strings.TrimRight(string(w.pending[:i]), "\r"))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	if len(w.pending) > 0 {
+		w.log.Println(string(w.pending))
+		w.pending = nil
+	}
+}
+
+type logKey struct{}
+
+// WithLog returns a copy of ctx whose commands write their output to log.
+func WithLog(ctx context.Context, log *Log) context.Context {
+	return context.WithValue(ctx, logKey{}, log)
+}
+
+// logFrom returns the log of ctx, or nil if it has none.
+func logFrom(ctx context.Context) *Log {
+	log, _ := ctx.Value(logKey{}).(*Log)
+	return log
+}
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"os/exec"
 )
 
@@ -31,21 +35,72 @@ func (s *NPMService) IsInstalled(binaryName string) bool {
 
 // Install installs a package globally using `npm install -g`.
 // The pkg.Name is the npm package name, pkg.InstallPath can be used for specific versions.
-func (s *NPMService) Install(pkg Package) error {
-	installName := pkg.Name
+func (s *NPMService) Install(ctx context.Context, pkg Package) error {
+	cmd := exec.Command("npm", "install", "-g", installName(pkg))
+	return Run(ctx, cmd)
+}
+
+// InstallCommand returns the command line Install runs for pkg.
+func (s *NPMService) InstallCommand(pkg Package) string {
+	return "npm install -g " + installName(pkg)
+}
+
+// InstallDev installs a package as a devDependency of the project in dir
+// using `npm install --save-dev`.
+func (s *NPMService) InstallDev(ctx context.Context, pkg Package, dir string) error {
+	cmd := exec.Command("npm", "install", installName(pkg), "--save-dev")
+	cmd.Dir = dir
+	return Run(ctx, cmd)
+}
+
+// InstallDevCommand returns the command line InstallDev runs for pkg.
+func (s *NPMService) InstallDevCommand(pkg Package) string {
+	return "npm install " + installName(pkg) + " --save-dev"
+}
+
+func installName(pkg Package) string {
 	if pkg.InstallPath != "" {
-		installName = pkg.InstallPath
+		return pkg.InstallPath
+	}
+	return pkg.Name
+}
+
+// InstalledVersion returns the installed version of a package, looking in
+// dir's node_modules or, when dir is empty, the global packages.
+func (s *NPMService) InstalledVersion(ctx context.Context, name, dir string) (string, error) {
+	args := []string{"ls", name, "--json", "--depth=0"}
+	if dir == "" {
+		args = append(args, "-g")
+	}
+	var out bytes.Buffer
+	cmd := exec.Command("npm", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	if err := Run(ctx, cmd); err != nil {
+		return "", err
+	}
+
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &tree); err != nil {
+		return "", err
+	}
+	dep, ok := tree.Dependencies[name]
+	if !ok || dep.Version == "" {
+		return "", fmt.Errorf("npm package %s is not installed", name)
 	}
-	cmd := exec.Command("npm", "install", "-g", installName)
-	return cmd.Run()
+	return dep.Version, nil
 }
 
 // Uninstall removes a package globally using `npm uninstall -g`.
 // Note: This is typically not called as we don't want to remove global packages
 // that might be used by other projects.
-func (s *NPMService) Uninstall(binaryName string) error {
+func (s *NPMService) Uninstall(ctx context.Context, binaryName string) error {
 	cmd := exec.Command("npm", "uninstall", "-g", binaryName)
-	return cmd.Run()
+	return Run(ctx, cmd)
 }
 
 // Global instance for convenience
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"os/exec"
+	"regexp"
+
+	"code-template/models"
+)
+
+// Requirements shared by several modules.
+var (
+	GoRequirement    = CommandRequirement("go", "install Go from https://go.dev/dl/", "version")
+	NpmRequirement   = CommandRequirement("npm", "install Node.js from https://nodejs.org/", "--version")
+	NpxRequirement   = CommandRequirement("npx", "install Node.js from https://nodejs.org/", "--version")
+	TaskRequirement  = CommandRequirement("task", TaskTool.GoInstallHint(TaskInstallPath), "--version")
+	WailsRequirement = CommandRequirement("wails", WailsTool.GoInstallHint(WailsInstallPath), "version")
+)
+
+// versionPattern matches the first version number in a tool's output,
+// e.g. "1.25.3" in "go version go1.25.3 linux/amd64".
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// CommandRequirement requires name in PATH. Its version is read from what
+// name prints when run with versionArgs; without them it is unknown.
+func CommandRequirement(name, hint string, versionArgs ...string) models.Requirement {
+	r := models.Requirement{
+		Name: name,
+		Hint: hint,
+		Found: func() bool {
+			_, err := exec.LookPath(name)
+			return err == nil
+		},
+	}
+	if len(versionArgs) > 0 {
+		r.Version = func() string {
+			ctx, cancel := probeContext()
+			defer cancel()
+			out, err := exec.CommandContext(ctx, name, versionArgs...).CombinedOutput()
+			if err != nil {
+				return ""
+			}
+			return versionPattern.FindString(string(out))
+		}
+	}
+	return r
+}
+
+// RequirementFor returns the shared requirement for a command, or a plain
+// PATH check for commands no module shares.
+func RequirementFor(command string) models.Requirement {
+	for _, r := range []models.Requirement{GoRequirement, NpmRequirement, NpxRequirement, TaskRequirement, WailsRequirement} {
+		if r.Name == command {
+			return r
+		}
+	}
+	return CommandRequirement(command, "install "+command+" and add it to PATH")
+}
+
+// LibraryRequirement requires a system library that pkg-config knows as
+// name.
+func LibraryRequirement(name, hint string) models.Requirement {
+	return models.Requirement{
+		Name: name,
+		Hint: hint,
+		Found: func() bool {
+			ctx, cancel := probeContext()
+			defer cancel()
+			return exec.CommandContext(ctx, "pkg-config", "--exists", name).Run() == nil
+		},
+		Version: func() string {
+			ctx, cancel := probeContext()
+			defer cancel()
+			out, err := exec.CommandContext(ctx, "pkg-config", "--modversion", name).Output()
+			if err != nil {
+				return ""
+			}
+			return versionPattern.FindString(string(out))
+		},
+	}
+}
+
+// AnyRequirement is met by the first of alternatives that is found and
+// reports that one's version.
+func AnyRequirement(name, hint string, alternatives ...models.Requirement) models.Requirement {
+	first := func() (models.Requirement, bool) {
+		for _, r := range alternatives {
+			if r.Found() {
+				return r, true
+			}
+		}
+		return models.Requirement{}, false
+	}
+	return models.Requirement{
+		Name: name,
+		Hint: hint,
+		Found: func() bool {
+			_, ok := first()
+			return ok
+		},
+		Version: func() string {
+			r, ok := first()
+			if !ok || r.Version == nil {
+				return ""
+			}
+			return r.Name + " " + r.Version()
+		},
+	}
+}
@@ -1,11 +1,29 @@
 package services
 
+import (
+	"context"
+	"strings"
+)
+
 // Package represents a dependency that can be installed.
 type Package struct {
 	Name        string // Binary/command name (e.g., "golangci-lint")
 	InstallPath string // Install path (e.g., "github.com/golangci/golangci-lint/v2/cmd/golangci-lint@latest")
 }
 
+// At returns the package pinned to version, replacing any version suffix in
+// InstallPath (e.g. "@latest"). Scoped npm names like "@scope/pkg" are kept.
+func (p Package) At(version string) Package {
+	path := p.InstallPath
+	if path == "" {
+		path = p.Name
+	}
+	if i := strings.LastIndex(path, "@"); i > 0 {
+		path = path[:i]
+	}
+	return Package{Name: p.Name, InstallPath: path + "@" + version}
+}
+
 // PackageService defines the interface for managing packages.
 type PackageService interface {
 	// Name returns the service name (e.g., "go", "npm").
@@ -18,8 +36,8 @@ type PackageService interface {
 	IsInstalled(binaryName string) bool
 
 	// Install installs a package. Returns error if installation fails.
-	Install(pkg Package) error
+	Install(ctx context.Context, pkg Package) error
 
 	// Uninstall removes a package. Returns error if uninstallation fails.
-	Uninstall(binaryName string) error
+	Uninstall(ctx context.Context, binaryName string) error
 }
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"code-template/helpers/project"
+	yamlhelper "code-template/helpers/yaml"
+)
+
+// toolsKey is the code-template.yml section that overrides the versions
+// modules pin their tools at, keyed by tool name:
+//
+//	tools:
+//	  golangci-lint: v2.8.0
+//	  tdd-guard: 1.1.0
+const toolsKey = "tools"
+
+// Tool is an external tool a module installs at a pinned version, so every
+// checkout of a repo runs the same release.
+type Tool struct {
+	Name        string   // Command name, and key in code-template.yml's tools section
+	Pinned      string   // Version installed unless code-template.yml overrides it
+	VersionArgs []string // Arguments that make the tool print its version
+}
+
+// Tools shared by several modules or by code-template itself.
+var (
+	TaskTool  = Tool{Name: "task", Pinned: "v3.44.0", VersionArgs: []string{"--version"}}
+	WailsTool = Tool{Name: "wails", Pinned: "v2.10.2", VersionArgs: []string{"version"}}
+)
+
+// Go packages of the shared tools.
+const (
+	TaskInstallPath  = "github.com/go-task/task/v3/cmd/task"
+	WailsInstallPath = "github.com/wailsapp/wails/v2/cmd/wails"
+)
+
+// Version returns the version to install t at: the override in the
+// workspace root's code-template.yml, or t.Pinned.
+func (t Tool) Version() string {
+	value, exists, err := yamlhelper.GetValue(filepath.Join(project.WorkspaceRoot(), project.ConfigFile), toolsKey)
+	if err != nil || !exists {
+		return t.Pinned
+	}
+	if versions, ok := value.(map[string]any); ok {
+		if v, ok := versions[t.Name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return t.Pinned
+}
+
+// Package returns the Go or npm package at installPath pinned to Version.
+func (t Tool) Package(installPath string) Package {
+	return Package{Name: t.Name, InstallPath: installPath}.At(t.Version())
+}
+
+// InstalledVersion runs the tool at path, or t.Name from PATH when path is
+// "", and returns the version it prints, or "" if that can't be told.
+func (t Tool) InstalledVersion(path string) string {
+	if path == "" {
+		path = t.Name
+	} else if abs, err := filepath.Abs(project.Path(path)); err == nil {
+		path = abs
+	}
+	ctx, cancel := probeContext()
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, t.VersionArgs...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return versionPattern.FindString(string(out))
+}
+
+// Mismatch describes how the tool at path (see InstalledVersion) differs
+// from Version, e.g. "golangci-lint 2.1.0, want v2.8.0". It returns "" when
+// they match.
+func (t Tool) Mismatch(path string) string {
+	return t.VersionMismatch(t.InstalledVersion(path))
+}
+
+// VersionMismatch describes how the installed version have differs from
+// Version, like Mismatch; have is "" when unknown.
+func (t Tool) VersionMismatch(have string) string {
+	want := t.Version()
+	have = versionPattern.FindString(have)
+	if have != "" && have == versionPattern.FindString(want) {
+		return ""
+	}
+	if have == "" {
+		have = "of unknown version"
+	}
+	return fmt.Sprintf("%s %s, want %s", t.Name, have, want)
+}
+
+// GoInstallHint tells how to install t with go install from installPath.
+func (t Tool) GoInstallHint(installPath string) string {
+	return "go install " + installPath + "@" + t.Pinned
+}
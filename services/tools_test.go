@@ -0,0 +1,30 @@
+package services
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTool_ReportsMismatchWithPinnedOrOverriddenVersion(t *testing.T) {
+	t.Chdir(t.TempDir())
+	os.WriteFile("lint", []byte("#!/bin/sh\necho \"lint has version 2.1.0 built with go1.25\"\n"), 0755)
+	tool := Tool{Name: "lint", Pinned: "v2.1.0", VersionArgs: []string{"--version"}}
+
+	if got := tool.Mismatch("lint"); got != "" {
+		t.Errorf("expected the pinned version to match, got %q", got)
+	}
+	if got := tool.Package("example.com/lint@latest").InstallPath; got != "example.com/lint@v2.1.0" {
+		t.Errorf("expected the pinned install path, got %q", got)
+	}
+
+	os.WriteFile("code-template.yml", []byte("tools:\n  lint: v2.8.0\n"), 0644)
+	if got := tool.Version(); got != "v2.8.0" {
+		t.Errorf("expected the override from code-template.yml, got %q", got)
+	}
+	if got := tool.Mismatch("lint"); got != "lint 2.1.0, want v2.8.0" {
+		t.Errorf("unexpected mismatch %q", got)
+	}
+	if got := tool.Mismatch("missing"); got != "lint of unknown version, want v2.8.0" {
+		t.Errorf("unexpected mismatch for a missing tool %q", got)
+	}
+}